@@ -6,10 +6,13 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/dnguy078/healthcheck/pkg/api"
+	"github.com/dnguy078/healthcheck/pkg/config"
+	"github.com/dnguy078/healthcheck/pkg/notify"
 	"github.com/dnguy078/healthcheck/pkg/service"
 	"github.com/dnguy078/healthcheck/pkg/storage"
 )
@@ -21,14 +24,47 @@ var (
 	runSSL    bool
 	frequency string
 	dataFile  string
+
+	configFile string
+
+	historyDepth     int
+	historyRetention string
+
+	failureThreshold int
+	successThreshold int
+
+	notifiersFile string
+
+	smtpHost     string
+	smtpPort     int
+	smtpStartTLS bool
+	smtpUsername string
+	smtpPassword string
+	smtpFrom     string
+	smtpTo       string
+	smtpDigest   string
 )
 
 func init() {
 	flag.StringVar(&address, "bind", "127.0.0.1:8080", "address to bind to")
 	flag.StringVar(&sslCert, "sslCert", "cert.pem", "ssl cert")
 	flag.StringVar(&sslKey, "sslKey", "key.pem", "ssl key")
-	flag.StringVar(&frequency, "checkfrequency", "3s", "frequency to run registered healthchecks")
+	flag.StringVar(&frequency, "checkfrequency", "3s", "default frequency to run registered healthchecks that do not set their own interval")
 	flag.StringVar(&dataFile, "datafile", "./pkg/storage/temp/data.json", "file containing existing healthchecks, loaded from disk")
+	flag.StringVar(&configFile, "config", "", "YAML or JSON file of healthchecks to manage, reconciled on startup and on SIGHUP")
+	flag.IntVar(&historyDepth, "historydepth", 1000, "number of results kept per healthcheck, 0 disables history")
+	flag.StringVar(&historyRetention, "historyretention", "720h", "how long results are kept per healthcheck")
+	flag.IntVar(&failureThreshold, "failurethreshold", 3, "consecutive failures before a healthcheck is marked DOWN")
+	flag.IntVar(&successThreshold, "successthreshold", 1, "consecutive successes before a healthcheck is marked UP")
+	flag.StringVar(&notifiersFile, "notifiers", "", "JSON file configuring notifiers sent state changes, ie) webhooks")
+	flag.StringVar(&smtpHost, "smtphost", "", "smtp server to email state changes through, email is disabled when empty")
+	flag.IntVar(&smtpPort, "smtpport", 587, "smtp server port")
+	flag.BoolVar(&smtpStartTLS, "smtpstarttls", true, "upgrade the smtp connection with STARTTLS")
+	flag.StringVar(&smtpUsername, "smtpusername", "", "smtp username, auth is disabled when empty")
+	flag.StringVar(&smtpPassword, "smtppassword", "", "smtp password")
+	flag.StringVar(&smtpFrom, "smtpfrom", "", "address state change emails are sent from")
+	flag.StringVar(&smtpTo, "smtpto", "", "comma separated addresses state change emails are sent to")
+	flag.StringVar(&smtpDigest, "smtpdigest", "1m", "state changes within this window are sent in a single email, 0 sends an email per change")
 	flag.BoolVar(&runSSL, "runSSL", false, "run with ssl")
 	flag.Parse()
 }
@@ -41,25 +77,119 @@ func main() {
 		log.Fatal(err)
 	}
 
+	retention, err := time.ParseDuration(historyRetention)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := db.SetHistoryLimits(historyDepth, retention); err != nil {
+		log.Fatal(err)
+	}
+
+	if configFile != "" {
+		if err := reconcileConfig(db); err != nil {
+			log.Fatal(err)
+		}
+		go reloadConfig(db)
+	}
+
 	reporter, err := service.NewReporter(checkfrequency, db)
 	if err != nil {
 		log.Fatal(err)
 	}
+	reporter.SetThresholds(failureThreshold, successThreshold)
+
+	var notifiers []notify.Notifier
+	if notifiersFile != "" {
+		if notifiers, err = notify.LoadConfig(notifiersFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if smtpHost != "" {
+		n, err := newSMTPNotifier()
+		if err != nil {
+			log.Fatal(err)
+		}
+		notifiers = append(notifiers, n)
+	}
+	deliveries := notify.NewDeliveryLog(1000)
+	dispatcher := notify.NewDispatcher(deliveries, notifiers...)
+	dispatcher.Start()
+	reporter.SetNotifier(dispatcher)
 	reporter.Report()
 
-	s, err := api.NewServer(address, sslCert, sslKey, db)
+	s, err := api.NewServer(address, sslCert, sslKey, db, deliveries)
 	if err != nil {
 		log.Fatal(err)
 	}
 	s.Start()
-	handleGracefulShutdown(s, db, reporter)
+	handleGracefulShutdown(s, db, reporter, dispatcher)
+}
+
+// newSMTPNotifier builds the email notifier from the smtp flags
+func newSMTPNotifier() (*notify.SMTP, error) {
+	digest, err := time.ParseDuration(smtpDigest)
+	if err != nil {
+		return nil, err
+	}
+
+	var to []string
+	for _, addr := range strings.Split(smtpTo, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+
+	return notify.NewSMTP(notify.SMTPConfig{
+		Host:     smtpHost,
+		Port:     smtpPort,
+		StartTLS: smtpStartTLS,
+		Username: smtpUsername,
+		Password: smtpPassword,
+		From:     smtpFrom,
+		To:       to,
+		Digest:   digest,
+	})
+}
+
+// reconcileConfig makes the healthchecks managed by the config file match it, logging each change
+func reconcileConfig(db *storage.Collection) error {
+	f, err := config.Load(configFile)
+	if err != nil {
+		return err
+	}
+
+	changes, err := config.Reconcile(db, f)
+	for _, c := range changes {
+		log.Printf("config %s: %s", configFile, c)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("config %s: reconciled %d checks, %d changes", configFile, len(f.Checks), len(changes))
+	return nil
+}
+
+// reloadConfig reconciles the config file on each SIGHUP. An invalid file is logged and the checks are left
+// as they are
+func reloadConfig(db *storage.Collection) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	for range hup {
+		if err := reconcileConfig(db); err != nil {
+			log.Printf("unable to reload config %s, err: %s", configFile, err)
+		}
+	}
 }
 
 // handleGracefulShutdown listens for sig iterrupts, kills to gracefully shutdown. Existing healthchecks
-// are written to disk
-func handleGracefulShutdown(api *api.Server, db *storage.Collection, reporter *service.Reporter) {
-	quit := make(chan os.Signal)
-	signal.Notify(quit, os.Interrupt, os.Kill, syscall.SIGHUP)
+// are written to disk. SIGHUP also shuts down unless it reloads the config file
+func handleGracefulShutdown(api *api.Server, db *storage.Collection, reporter *service.Reporter, dispatcher *notify.Dispatcher) {
+	quit := make(chan os.Signal, 1)
+	signals := []os.Signal{os.Interrupt, os.Kill}
+	if configFile == "" {
+		signals = append(signals, syscall.SIGHUP)
+	}
+	signal.Notify(quit, signals...)
 	<-quit
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -71,6 +201,7 @@ func handleGracefulShutdown(api *api.Server, db *storage.Collection, reporter *s
 	log.Printf("wrote existing healthchecks to disk, file: %s", dataFile)
 
 	reporter.Stop()
+	dispatcher.Stop()
 
 	if err := api.Stop(ctx); err != nil {
 		log.Printf("unable to stop http server, err: %s", err)
@@ -1,3 +1,9 @@
 module github.com/dnguy078/healthcheck
 
 go 1.13
+
+require (
+	golang.org/x/net v0.0.0-20191209160850-c0dbc17a3553
+	google.golang.org/grpc v1.26.0
+	gopkg.in/yaml.v2 v2.4.0
+)
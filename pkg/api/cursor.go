@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/utils"
+)
+
+const (
+	// cursorTTL is how long a cursor scan can go without fetching its next page
+	cursorTTL = 5 * time.Minute
+	// maxCursors caps the open scans, the least recently used is dropped first
+	maxCursors = 1000
+)
+
+var errInvalidCursor = errors.New("invalid or expired cursor")
+
+// cursorStore keeps the ordered ids of open cursor scans. A scan is a snapshot of the filtered and sorted
+// list taken on its first page, so the Reporter updating results mid-scan cannot reorder it, skip items or
+// repeat them. The zero value is ready to use
+type cursorStore struct {
+	mu    sync.Mutex
+	scans map[string]*scan
+}
+
+type scan struct {
+	ids     []string
+	expires time.Time
+}
+
+// start snapshots the ids of a new scan, returning its token
+func (cs *cursorStore) start(ids []string, now time.Time) (string, error) {
+	token, err := utils.UUID()
+	if err != nil {
+		return "", err
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.prune(now)
+	if cs.scans == nil {
+		cs.scans = map[string]*scan{}
+	}
+	cs.scans[token] = &scan{ids: ids, expires: now.Add(cursorTTL)}
+	return token, nil
+}
+
+// get returns the ids of an open scan, extending its expiry
+func (cs *cursorStore) get(token string, now time.Time) ([]string, bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	s, ok := cs.scans[token]
+	if !ok || now.After(s.expires) {
+		delete(cs.scans, token)
+		return nil, false
+	}
+	s.expires = now.Add(cursorTTL)
+	return s.ids, true
+}
+
+// finish drops a scan once its last page is fetched
+func (cs *cursorStore) finish(token string) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	delete(cs.scans, token)
+}
+
+// prune drops expired scans, and the scans closest to expiring while there are too many
+func (cs *cursorStore) prune(now time.Time) {
+	for token, s := range cs.scans {
+		if now.After(s.expires) {
+			delete(cs.scans, token)
+		}
+	}
+	for len(cs.scans) >= maxCursors {
+		var oldest string
+		for token, s := range cs.scans {
+			if oldest == "" || s.expires.Before(cs.scans[oldest].expires) {
+				oldest = token
+			}
+		}
+		delete(cs.scans, oldest)
+	}
+}
+
+// encodeCursor returns the opaque cursor for the position of a scan
+func encodeCursor(token string, offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%d", token, offset)))
+}
+
+// decodeCursor returns the scan token and position of a cursor
+func decodeCursor(cursor string) (string, int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, errInvalidCursor
+	}
+	parts := strings.SplitN(string(b), ":", 2)
+	if len(parts) != 2 {
+		return "", 0, errInvalidCursor
+	}
+	offset, err := strconv.Atoi(parts[1])
+	if err != nil || offset < 0 {
+		return "", 0, errInvalidCursor
+	}
+	return parts[0], offset, nil
+}
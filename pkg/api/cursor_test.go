@@ -0,0 +1,71 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCursorStore(t *testing.T) {
+	now := time.Now()
+	cs := &cursorStore{}
+	token, err := cs.start([]string{"a", "b"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ids, ok := cs.get(token, now.Add(cursorTTL/2)); !ok || len(ids) != 2 {
+		t.Errorf("expected the scan to be open, got %v, %v", ids, ok)
+	}
+	// fetching a page extends the expiry
+	if _, ok := cs.get(token, now.Add(cursorTTL)); !ok {
+		t.Error("expected the scan expiry to be extended")
+	}
+	if _, ok := cs.get(token, now.Add(3*cursorTTL)); ok {
+		t.Error("expected the scan to expire")
+	}
+	if _, ok := cs.get("unknown", now); ok {
+		t.Error("expected an unknown scan to be closed")
+	}
+}
+
+func TestCursorStore_Prune(t *testing.T) {
+	now := time.Now()
+	cs := &cursorStore{}
+	first, _ := cs.start(nil, now)
+	for i := 1; i < maxCursors; i++ {
+		cs.start(nil, now.Add(time.Duration(i)))
+	}
+	if _, ok := cs.get(first, now); !ok {
+		t.Fatal("expected the first scan to be open")
+	}
+	cs.start(nil, now.Add(time.Duration(maxCursors)))
+	if len(cs.scans) != maxCursors {
+		t.Errorf("expected %d scans, got %d", maxCursors, len(cs.scans))
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	tests := []struct {
+		name    string
+		cursor  string
+		token   string
+		offset  int
+		wantErr bool
+	}{
+		{name: "round trip", cursor: encodeCursor("token", 20), token: "token", offset: 20},
+		{name: "not base64", cursor: "???", wantErr: true},
+		{name: "no offset", cursor: "dG9rZW4", wantErr: true},
+		{name: "negative offset", cursor: encodeCursor("token", -1), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, offset, err := decodeCursor(tt.cursor)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeCursor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if token != tt.token || offset != tt.offset {
+				t.Errorf("decodeCursor() = %s, %d, want %s, %d", token, offset, tt.token, tt.offset)
+			}
+		})
+	}
+}
@@ -2,98 +2,368 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/dnguy078/healthcheck/pkg/labels"
 	"github.com/dnguy078/healthcheck/pkg/models"
 	"github.com/dnguy078/healthcheck/pkg/service"
 	"github.com/dnguy078/healthcheck/pkg/utils"
 )
 
 type HealthCheckHandler struct {
-	db healthCheckStorage
+	db      healthCheckStorage
+	cursors cursorStore
 }
 
 type healthCheckStorage interface {
 	List() models.HealthChecks
 	Get(id string) (*models.HealthCheck, error)
 	Create(*models.HealthCheck) error
-	Delete(id string)
+	CreateBatch(checks []*models.HealthCheck, dryRun bool) []error
+	Update(*models.HealthCheck) error
+	Delete(id string) error
+	DeleteBatch(ids []string, dryRun bool) []error
+	History(id string, from, to time.Time) ([]models.Result, error)
+	Stats(id string, now time.Time) ([]models.WindowStats, error)
 }
 
-func (hh *HealthCheckHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+const (
+	defaultListPageSize    = 10
+	maxListPageSize        = 100
+	maxBatchSize           = 1000
+	defaultHistoryPageSize = 10
+	maxHistoryPageSize     = 100
+)
+
+// Routes registers the healthcheck endpoints
+func (hh *HealthCheckHandler) Routes(rt *Router) {
+	rt.Handle("/api/health/checks", hh.List, http.MethodGet)
+	rt.Handle("/api/health/checks", hh.Create, http.MethodPost)
+	rt.Handle("/api/health/checks:batch", hh.BatchCreate, http.MethodPost)
+	rt.Handle("/api/health/checks:batch", hh.BatchDelete, http.MethodDelete)
+	rt.Handle("/api/health/checks/{id}", hh.Get, http.MethodGet)
+	rt.Handle("/api/health/checks/{id}", hh.Update, http.MethodPut, http.MethodPatch)
+	rt.Handle("/api/health/checks/{id}", hh.Delete, http.MethodDelete)
+	rt.Handle("/api/health/checks/{id}/try", hh.Execute, http.MethodPost)
+	rt.Handle("/api/health/checks/{id}/history", hh.History, http.MethodGet)
+	rt.Handle("/api/health/checks/{id}/stats", hh.Stats, http.MethodGet)
+}
+
+// List returns a paginated list of healthchecks. The list can be filtered by the last verdict with
+// ?healthy=true|false, by status code with ?status=200-299, by state with ?state=DOWN,UNREACHABLE, by
+// labels with ?label=team=payments,env!=staging and by a substring of the endpoint with ?q=. Repeated
+// filters must all match. It is sorted by ?sort=endpoint|checked|duration|status and ?order=asc|desc,
+// and paged with ?page= and ?size=. Passing ?cursor= instead of ?page= pages through a snapshot of the
+// list with the returned next_cursor. Stats are inlined with ?stats=true
+func (hh *HealthCheckHandler) List(w http.ResponseWriter, r *http.Request) {
 	queryParams := r.URL.Query()
-	switch r.Method {
-	case http.MethodGet:
-		if utils.ContainsUUID(r.URL.String()) {
-			hh.Get(w, r)
+	page, err := intParam(queryParams, "page", 0)
+	if err != nil || page < 0 {
+		writeError(w, "invalid page", http.StatusBadRequest)
+		return
+	}
+	size, err := intParam(queryParams, "size", defaultListPageSize)
+	if err != nil || size <= 0 || size > maxListPageSize {
+		writeError(w, fmt.Sprintf("size must be between 1 and %d", maxListPageSize), http.StatusBadRequest)
+		return
+	}
+	_, cursorMode := queryParams["cursor"]
+	if cursorMode && queryParams.Get("page") != "" {
+		writeError(w, "page and cursor cannot be combined", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	res := &models.HealthCheckList{
+		Page: page,
+		Size: size,
+	}
+	if cursor := queryParams.Get("cursor"); cursor != "" {
+		// later pages of a scan keep the filters and order of its first page
+		token, offset, err := decodeCursor(cursor)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		if _, ok := queryParams["page"]; ok {
-			hh.List(w, r)
+		ids, ok := hh.cursors.get(token, now)
+		if !ok || offset > len(ids) {
+			writeError(w, errInvalidCursor.Error(), http.StatusBadRequest)
 			return
 		}
-	case http.MethodPost:
-		if utils.ContainsUUID(r.URL.String()) {
-			hh.Execute(w, r)
+		res.Items, res.Total, res.NextCursor = hh.scanPage(token, ids, offset, size)
+	} else {
+		filters, err := listFilters(queryParams)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		hh.Create(w, r)
-		return
-	case http.MethodDelete:
-		hh.Delete(w, r)
+		list := filter(hh.db.List(), filters)
+		if err := sortChecks(list, queryParams.Get("sort"), queryParams.Get("order")); err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if cursorMode {
+			ids := make([]string, 0, len(list))
+			for _, hc := range list {
+				ids = append(ids, hc.ID)
+			}
+			token, err := hh.cursors.start(ids, now)
+			if err != nil {
+				writeError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			res.Items, res.Total, res.NextCursor = hh.scanPage(token, ids, 0, size)
+		} else {
+			start, end := paginate(page, size, len(list))
+			res.Items = list[start:end]
+			res.Total = len(list)
+		}
+	}
+
+	if inline, _ := strconv.ParseBool(queryParams.Get("stats")); inline {
+		for _, hc := range res.Items {
+			windows, err := hh.db.Stats(hc.ID, now)
+			if err != nil {
+				continue
+			}
+			hc.Stats = windows
+		}
+	}
+	for i, hc := range res.Items {
+		res.Items[i] = hc.Redacted()
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
 		return
+	}
+
+	w.Write(b)
+}
+
+// scanPage returns the current healthchecks for a page of a cursor scan, the size of the scan and the
+// cursor of the next page. Healthchecks deleted since the scan started are left out. offset cannot be
+// past the end of the scan
+func (hh *HealthCheckHandler) scanPage(token string, ids []string, offset, size int) (models.HealthChecks, int, string) {
+	start, end := offset, offset+size
+	if end > len(ids) {
+		end = len(ids)
+	}
+
+	items := make(models.HealthChecks, 0, end-start)
+	for _, id := range ids[start:end] {
+		if hc, err := hh.db.Get(id); err == nil {
+			items = append(items, hc)
+		}
+	}
+
+	if end == len(ids) {
+		hh.cursors.finish(token)
+		return items, len(ids), ""
+	}
+	return items, len(ids), encodeCursor(token, end)
+}
+
+// sortChecks sorts healthchecks by endpoint, checked, duration or status in asc or desc order. Ties are
+// ordered by endpoint then id so pages are stable
+func sortChecks(list models.HealthChecks, by, order string) error {
+	var less func(a, b *models.HealthCheck) bool
+	switch by {
+	case "", "endpoint":
+		less = func(a, b *models.HealthCheck) bool { return false }
+	case "checked":
+		less = func(a, b *models.HealthCheck) bool { return a.Checked < b.Checked }
+	case "duration":
+		less = func(a, b *models.HealthCheck) bool { return a.DurationMs < b.DurationMs }
+	case "status":
+		less = func(a, b *models.HealthCheck) bool { return a.Code < b.Code }
+	default:
+		return fmt.Errorf("invalid sort %q, expected endpoint, checked, duration or status", by)
+	}
+
+	var desc bool
+	switch strings.ToLower(order) {
+	case "", "asc":
+	case "desc":
+		desc = true
 	default:
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		return fmt.Errorf("invalid order %q, expected asc or desc", order)
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if desc {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		if a.Endpoint != b.Endpoint {
+			return a.Endpoint < b.Endpoint
+		}
+		return a.ID < b.ID
+	})
+	return nil
+}
+
+// listFilters parses the healthy, status, state, q and label query params into filters
+func listFilters(queryParams url.Values) ([]func(*models.HealthCheck) bool, error) {
+	var filters []func(*models.HealthCheck) bool
+	for _, v := range queryParams["healthy"] {
+		want, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, func(hc *models.HealthCheck) bool { return hc.Healthy == want })
+	}
+	for _, v := range queryParams["status"] {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+		codes, err := service.ParseStatusCodes(v)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, func(hc *models.HealthCheck) bool { return codes.Match(int(hc.Code)) })
+	}
+	for _, v := range queryParams["state"] {
+		states := map[string]bool{}
+		for _, state := range strings.Split(v, ",") {
+			if state = strings.ToUpper(strings.TrimSpace(state)); state != "" {
+				states[state] = true
+			}
+		}
+		if len(states) > 0 {
+			filters = append(filters, func(hc *models.HealthCheck) bool { return states[hc.State] })
+		}
+	}
+	for _, v := range queryParams["q"] {
+		q := strings.ToLower(v)
+		filters = append(filters, func(hc *models.HealthCheck) bool { return strings.Contains(strings.ToLower(hc.Endpoint), q) })
+	}
+	for _, v := range queryParams["label"] {
+		selector, err := labels.Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, func(hc *models.HealthCheck) bool { return selector.Matches(hc.Labels) })
+	}
+	return filters, nil
+}
+
+// filter returns the healthchecks matching every filter
+func filter(list models.HealthChecks, filters []func(*models.HealthCheck) bool) models.HealthChecks {
+	filtered := make(models.HealthChecks, 0, len(list))
+next:
+	for _, hc := range list {
+		for _, f := range filters {
+			if !f(hc) {
+				continue next
+			}
+		}
+		filtered = append(filtered, hc)
+	}
+	return filtered
+}
+
+// Get returns a specific healthcheck
+func (hh *HealthCheckHandler) Get(w http.ResponseWriter, r *http.Request) {
+	uuid := pathID(r)
+	hc, err := hh.db.Get(uuid)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusNotFound)
 		return
 	}
+
+	b, err := json.Marshal(hc.Redacted())
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(b)
 }
 
-// List returns a paginated list of healthchecks
-func (hh *HealthCheckHandler) List(w http.ResponseWriter, r *http.Request) {
+// History returns a paginated list of a healthcheck's past results, newest first. Results can be limited to
+// a time range with ?from= and ?to= as unix timestamps or RFC3339
+func (hh *HealthCheckHandler) History(w http.ResponseWriter, r *http.Request) {
+	uuid := pathID(r)
 	queryParams := r.URL.Query()
-	page, err := strconv.Atoi(queryParams["page"][0])
+
+	from, err := parseTime(queryParams.Get("from"))
 	if err != nil {
-		http.Error(w, marshalError(err.Error()), http.StatusBadRequest)
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := parseTime(queryParams.Get("to"))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := intParam(queryParams, "page", 0)
+	if err != nil || page < 0 {
+		writeError(w, "invalid page", http.StatusBadRequest)
+		return
+	}
+	size, err := intParam(queryParams, "size", defaultHistoryPageSize)
+	if err != nil || size <= 0 || size > maxHistoryPageSize {
+		writeError(w, fmt.Sprintf("size must be between 1 and %d", maxHistoryPageSize), http.StatusBadRequest)
 		return
 	}
 
-	list := hh.db.List()
-	sort.Sort(models.HealthChecks(list))
-	start, end := paginate(page, 10, len(list))
-	paginated := list[start:end]
+	results, err := hh.db.History(uuid, from, to)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
 
-	res := &models.HealthCheckList{
-		Items: paginated,
-		Total: len(list),
+	start, end := paginate(page, size, len(results))
+	res := &models.HistoryList{
+		Items: results[start:end],
+		Total: len(results),
 		Page:  page,
-		Size:  10,
+		Size:  size,
 	}
 
 	b, err := json.Marshal(res)
 	if err != nil {
-		http.Error(w, marshalError(err.Error()), http.StatusInternalServerError)
+		writeError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Write(b)
 }
 
-// Get returns a specific healthcheck
-func (hh *HealthCheckHandler) Get(w http.ResponseWriter, r *http.Request) {
-	uuid := utils.ExtractUUID(r.URL.String())
-	hc, err := hh.db.Get(uuid)
+// Stats returns the uptime and latency percentiles of a healthcheck over rolling windows
+func (hh *HealthCheckHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	uuid := pathID(r)
+	windows, err := hh.db.Stats(uuid, time.Now())
 	if err != nil {
-		http.Error(w, marshalError(err.Error()), http.StatusNotFound)
+		writeError(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
-	b, err := json.Marshal(hc)
+	res := &models.HealthCheckStats{
+		ID:    uuid,
+		Stats: windows,
+	}
+
+	b, err := json.Marshal(res)
 	if err != nil {
-		http.Error(w, marshalError(err.Error()), http.StatusInternalServerError)
+		writeError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -104,89 +374,315 @@ func (hh *HealthCheckHandler) Get(w http.ResponseWriter, r *http.Request) {
 func (hh *HealthCheckHandler) Create(w http.ResponseWriter, r *http.Request) {
 	req := &models.CreateHealthCheckRequest{}
 	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
-		http.Error(w, marshalError(err.Error()), http.StatusBadRequest)
+		writeError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if req.Endpoint == "" {
-		http.Error(w, marshalError("empty healthcheck endpoint"), http.StatusBadRequest)
+	hc, err := hh.build(req, hh.db.List())
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if _, err := url.ParseRequestURI(req.Endpoint); err != nil {
-		http.Error(w, marshalError("invalid URL"), http.StatusBadRequest)
+	if err := hh.db.Create(hc); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	hc, err := models.NewHealthCheck(req.Endpoint)
+	resp := &models.CreateHealthCheckResponse{
+		ID:       hc.ID,
+		Endpoint: hc.Endpoint,
+	}
+
+	b, err := json.Marshal(resp)
 	if err != nil {
-		http.Error(w, marshalError(err.Error()), http.StatusInternalServerError)
+		writeError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	if err := hh.db.Create(hc); err != nil {
-		http.Error(w, marshalError(err.Error()), http.StatusBadRequest)
+	w.Header().Set("Location", "/api/health/checks/"+hc.ID)
+	w.WriteHeader(http.StatusCreated)
+	w.Write(b)
+}
+
+// Update changes a healthcheck's configuration, keeping its id, state and history. PUT replaces the whole
+// configuration like create, PATCH is a JSON merge patch that only changes the fields that are sent, ie)
+// {"labels": {"env": null}} removes the env label
+func (hh *HealthCheckHandler) Update(w http.ResponseWriter, r *http.Request) {
+	uuid := pathID(r)
+	existing, err := hh.db.Get(uuid)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
-	resp := &models.CreateHealthCheckResponse{
-		ID:       hc.ID,
-		Endpoint: hc.Endpoint,
+	req := &models.CreateHealthCheckRequest{}
+	if r.Method == http.MethodPatch {
+		req, err = mergePatch(existing.Request(), r.Body)
+	} else {
+		err = json.NewDecoder(r.Body).Decode(req)
+	}
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+	req.Unredact(existing)
 
-	b, err := json.Marshal(resp)
+	if err := service.ValidateEndpoint(req.Endpoint); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hc := existing.Copy()
+	req.Apply(hc)
+
+	if err := hh.validate(hc, hh.db.List()); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := hh.db.Update(hc); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := hh.db.Get(uuid)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	b, err := json.Marshal(updated.Redacted())
 	if err != nil {
-		http.Error(w, marshalError(err.Error()), http.StatusInternalServerError)
+		writeError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Write(b)
 }
 
-// Delete removes a healthcheck
-func (hh *HealthCheckHandler) Delete(w http.ResponseWriter, r *http.Request) {
-	uuid := utils.ExtractUUID(r.URL.String())
-	hh.db.Delete(uuid)
+// BatchCreate creates an array of healthchecks, responding with the result of each. Invalid and duplicate
+// healthchecks are reported and the others are created together. Nothing is created with ?dry_run=true
+func (hh *HealthCheckHandler) BatchCreate(w http.ResponseWriter, r *http.Request) {
+	dryRun, err := boolParam(r.URL.Query(), "dry_run")
+	if err != nil {
+		writeError(w, "invalid dry_run", http.StatusBadRequest)
+		return
+	}
+
+	var reqs []*models.CreateHealthCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(reqs) > maxBatchSize {
+		writeError(w, fmt.Sprintf("batches are limited to %d items", maxBatchSize), http.StatusBadRequest)
+		return
+	}
+
+	res := &models.BatchResponse{DryRun: dryRun, Results: make([]models.BatchResult, len(reqs))}
+	// every item is validated against one snapshot of the stored healthchecks
+	stored := hh.db.List()
+	var checks []*models.HealthCheck
+	var indexes []int
+	for i, req := range reqs {
+		res.Results[i] = models.BatchResult{Index: i}
+		if req == nil {
+			res.Results[i].Result = models.BatchInvalid
+			res.Results[i].Error = "empty healthcheck"
+			continue
+		}
+		res.Results[i].Endpoint = req.Endpoint
+
+		hc, err := hh.build(req, stored)
+		if err != nil {
+			res.Results[i].Result = models.BatchInvalid
+			res.Results[i].Error = err.Error()
+			continue
+		}
+		checks = append(checks, hc)
+		indexes = append(indexes, i)
+	}
+
+	errs := hh.db.CreateBatch(checks, dryRun)
+	for j, i := range indexes {
+		if errs[j] != nil {
+			res.Results[i].Result = models.BatchDuplicate
+			res.Results[i].Error = errs[j].Error()
+			continue
+		}
+		res.Results[i].Result = models.BatchCreated
+		if !dryRun {
+			res.Results[i].ID = checks[j].ID
+		}
+	}
+
+	writeJSON(w, res, http.StatusOK)
 }
 
-// Execute a healthcheck with a timeouts
-func (hh *HealthCheckHandler) Execute(w http.ResponseWriter, r *http.Request) {
-	uuid := utils.ExtractUUID(r.URL.String())
-	if uuid == "" {
-		http.Error(w, marshalError("invalid uuid"), http.StatusBadRequest)
+// BatchDelete deletes an array of healthcheck ids, responding with the result of each. Nothing is deleted
+// with ?dry_run=true
+func (hh *HealthCheckHandler) BatchDelete(w http.ResponseWriter, r *http.Request) {
+	dryRun, err := boolParam(r.URL.Query(), "dry_run")
+	if err != nil {
+		writeError(w, "invalid dry_run", http.StatusBadRequest)
 		return
 	}
 
-	queryParams := r.URL.Query()
-	timeout, err := time.ParseDuration(queryParams["timeout"][0])
+	var ids []string
+	if err := json.NewDecoder(r.Body).Decode(&ids); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(ids) > maxBatchSize {
+		writeError(w, fmt.Sprintf("batches are limited to %d items", maxBatchSize), http.StatusBadRequest)
+		return
+	}
+
+	res := &models.BatchResponse{DryRun: dryRun, Results: make([]models.BatchResult, len(ids))}
+	var valid []string
+	var indexes []int
+	for i, id := range ids {
+		res.Results[i] = models.BatchResult{Index: i, ID: id}
+		if !utils.IsUUID(id) {
+			res.Results[i].Result = models.BatchInvalid
+			res.Results[i].Error = "invalid uuid"
+			continue
+		}
+		valid = append(valid, id)
+		indexes = append(indexes, i)
+	}
+
+	errs := hh.db.DeleteBatch(valid, dryRun)
+	for j, i := range indexes {
+		if errs[j] != nil {
+			res.Results[i].Result = models.BatchNotFound
+			res.Results[i].Error = errs[j].Error()
+			continue
+		}
+		res.Results[i].Result = models.BatchDeleted
+	}
+
+	writeJSON(w, res, http.StatusOK)
+}
+
+// build returns a new healthcheck from a create request, validated against the stored healthchecks
+func (hh *HealthCheckHandler) build(req *models.CreateHealthCheckRequest, checks models.HealthChecks) (*models.HealthCheck, error) {
+	if err := service.ValidateEndpoint(req.Endpoint); err != nil {
+		return nil, err
+	}
+
+	hc, err := models.NewHealthCheck(req.Endpoint)
 	if err != nil {
-		http.Error(w, marshalError(err.Error()), http.StatusBadRequest)
+		return nil, err
+	}
+	req.Apply(hc)
+
+	if err := hh.validate(hc, checks); err != nil {
+		return nil, err
+	}
+	return hc, nil
+}
+
+// validate checks a healthcheck's configuration, and its parents and members against the stored healthchecks
+func (hh *HealthCheckHandler) validate(hc *models.HealthCheck, checks models.HealthChecks) error {
+	if err := service.Validate(hc); err != nil {
+		return err
+	}
+
+	if err := service.ValidateParents(hc, checks); err != nil {
+		return err
+	}
+	return service.ValidateMembers(hc, checks)
+}
+
+// Delete removes a healthcheck
+func (hh *HealthCheckHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	uuid := pathID(r)
+	if err := hh.db.Delete(uuid); err != nil {
+		writeError(w, err.Error(), http.StatusNotFound)
 		return
 	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Execute a healthcheck with a timeout
+func (hh *HealthCheckHandler) Execute(w http.ResponseWriter, r *http.Request) {
+	uuid := pathID(r)
 
 	hc, err := hh.db.Get(uuid)
 	if err != nil {
-		http.Error(w, marshalError(err.Error()), http.StatusBadRequest)
+		writeError(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
-	// make a copy and run the healthcheck
-	try := &models.HealthCheck{
-		ID:       hc.ID,
-		Endpoint: hc.Endpoint,
+	// the timeout query param takes precedence over the healthcheck's own timeout
+	timeout := service.Timeout(hc)
+	if t := r.URL.Query().Get("timeout"); t != "" {
+		if timeout, err = time.ParseDuration(t); err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 	}
 
-	try = service.Run(try, timeout)
+	// make a copy and run the healthcheck
+	try := service.Run(hc.Copy(), timeout)
 
-	b, err := json.Marshal(try)
+	b, err := json.Marshal(try.Redacted())
 	if err != nil {
-		http.Error(w, marshalError(err.Error()), http.StatusInternalServerError)
+		writeError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Write(b)
 }
 
+// mergePatch applies a JSON merge patch (RFC 7396) to a request. Objects like labels and headers are merged
+// key by key, a null value removes the key or resets the field, and any other value replaces it
+func mergePatch(req *models.CreateHealthCheckRequest, patch io.Reader) (*models.CreateHealthCheckRequest, error) {
+	var p map[string]interface{}
+	if err := json.NewDecoder(patch).Decode(&p); err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+
+	if b, err = json.Marshal(mergeObjects(doc, p)); err != nil {
+		return nil, err
+	}
+	patched := &models.CreateHealthCheckRequest{}
+	if err := json.Unmarshal(b, patched); err != nil {
+		return nil, err
+	}
+	return patched, nil
+}
+
+// mergeObjects merges a patch object into a document object
+func mergeObjects(doc, patch map[string]interface{}) map[string]interface{} {
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+	for k, v := range patch {
+		switch v := v.(type) {
+		case nil:
+			delete(doc, k)
+		case map[string]interface{}:
+			existing, _ := doc[k].(map[string]interface{})
+			doc[k] = mergeObjects(existing, v)
+		default:
+			doc[k] = v
+		}
+	}
+	return doc
+}
+
 // marshalError wraps a error
 func marshalError(errString string) string {
 	type endpointError struct {
@@ -200,12 +696,45 @@ func marshalError(errString string) string {
 	return string(b)
 }
 
-func paginate(pageNum int, pageSize int, sliceLength int) (int, int) {
-	start := pageNum * pageSize
+// boolParam parses an optional boolean query param, defaulting to false
+func boolParam(queryParams url.Values, name string) (bool, error) {
+	v := queryParams.Get(name)
+	if v == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
+
+// intParam parses an optional integer query param
+func intParam(queryParams url.Values, name string, def int) (int, error) {
+	v := queryParams.Get(name)
+	if v == "" {
+		return def, nil
+	}
+	return strconv.Atoi(v)
+}
+
+// parseTime parses an optional unix timestamp or RFC3339 time, an empty string is the zero time
+func parseTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if unix, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(unix, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected a unix timestamp or RFC3339", v)
+	}
+	return t, nil
+}
 
-	if start > sliceLength {
-		start = sliceLength
+func paginate(pageNum int, pageSize int, sliceLength int) (int, int) {
+	// pages past the end are empty, checked before multiplying so a huge page cannot overflow
+	if pageNum > sliceLength/pageSize {
+		return sliceLength, sliceLength
 	}
+	start := pageNum * pageSize
 
 	end := start + pageSize
 	if end > sliceLength {
@@ -3,11 +3,13 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/dnguy078/healthcheck/pkg/models"
 	"github.com/dnguy078/healthcheck/pkg/storage/mocks"
@@ -58,6 +60,45 @@ func TestHealthCheckHandler_List(t *testing.T) {
 			args: args{
 				r: httptest.NewRequest("GET", "/api/health/checks?page=0", nil),
 			},
+		}, {
+			name: "filter healthy",
+			fields: fields{
+				db: &mocks.FakeCollection{
+					ListResp: models.HealthChecks{
+						&models.HealthCheck{
+							Endpoint: "b",
+							Result:   models.Result{Healthy: true},
+						},
+						&models.HealthCheck{
+							Endpoint: "a",
+						},
+					},
+				},
+			},
+			want: models.HealthCheckList{
+				Items: models.HealthChecks{
+					&models.HealthCheck{
+						Endpoint: "b",
+						Result:   models.Result{Healthy: true},
+					},
+				},
+				Page:  0,
+				Size:  10,
+				Total: 1,
+			},
+			expectedStatusCode: http.StatusOK,
+			args: args{
+				r: httptest.NewRequest("GET", "/api/health/checks?page=0&healthy=true", nil),
+			},
+		}, {
+			name: "healthy filter error",
+			fields: fields{
+				db: &mocks.FakeCollection{},
+			},
+			args: args{
+				r: httptest.NewRequest("GET", "/api/health/checks?page=0&healthy=maybe", nil),
+			},
+			expectedStatusCode: http.StatusBadRequest,
 		}, {
 			name: "page error",
 			args: args{
@@ -89,6 +130,181 @@ func TestHealthCheckHandler_List(t *testing.T) {
 	}
 }
 
+func TestHealthCheckHandler_ListFilters(t *testing.T) {
+	db := &mocks.FakeCollection{
+		ListResp: models.HealthChecks{
+			&models.HealthCheck{
+				Endpoint: "https://pay.example.com",
+				Labels:   map[string]string{"team": "payments", "env": "production"},
+				Result:   models.Result{Code: 200, Healthy: true, Checked: 300, Duration: "20ms", DurationMs: 20},
+				Tracking: models.Tracking{State: models.StateUp},
+			},
+			&models.HealthCheck{
+				Endpoint: "https://pay.staging.example.com",
+				Labels:   map[string]string{"team": "payments", "env": "staging"},
+				Result:   models.Result{Code: 503, Checked: 100, Duration: "1.5s", DurationMs: 1500},
+				Tracking: models.Tracking{State: models.StateDown},
+			},
+			&models.HealthCheck{
+				Endpoint: "https://search.example.com",
+				Labels:   map[string]string{"team": "search"},
+				Result:   models.Result{Code: 502, Checked: 200, Duration: "300ms", DurationMs: 300},
+				Tracking: models.Tracking{State: models.StateUnreachable},
+			},
+		},
+	}
+
+	tests := []struct {
+		name               string
+		query              string
+		expectedStatusCode int
+		want               []string
+	}{
+		{name: "no filters", query: "", expectedStatusCode: http.StatusOK, want: []string{"https://pay.example.com", "https://pay.staging.example.com", "https://search.example.com"}},
+		{name: "label", query: "label=team=payments", expectedStatusCode: http.StatusOK, want: []string{"https://pay.example.com", "https://pay.staging.example.com"}},
+		{name: "label selector", query: "label=team=payments,env!=staging", expectedStatusCode: http.StatusOK, want: []string{"https://pay.example.com"}},
+		{name: "repeated labels", query: "label=team=payments&label=env=staging", expectedStatusCode: http.StatusOK, want: []string{"https://pay.staging.example.com"}},
+		{name: "label exists", query: "label=env", expectedStatusCode: http.StatusOK, want: []string{"https://pay.example.com", "https://pay.staging.example.com"}},
+		{name: "state", query: "state=down,unreachable", expectedStatusCode: http.StatusOK, want: []string{"https://pay.staging.example.com", "https://search.example.com"}},
+		{name: "status", query: "status=500-599", expectedStatusCode: http.StatusOK, want: []string{"https://pay.staging.example.com", "https://search.example.com"}},
+		{name: "combined", query: "status=500-599&label=team=search&state=UNREACHABLE", expectedStatusCode: http.StatusOK, want: []string{"https://search.example.com"}},
+		{name: "search", query: "q=PAY", expectedStatusCode: http.StatusOK, want: []string{"https://pay.example.com", "https://pay.staging.example.com"}},
+		{name: "search no match", query: "q=checkout", expectedStatusCode: http.StatusOK, want: nil},
+		{name: "sort endpoint desc", query: "order=desc", expectedStatusCode: http.StatusOK, want: []string{"https://search.example.com", "https://pay.staging.example.com", "https://pay.example.com"}},
+		{name: "sort checked", query: "sort=checked", expectedStatusCode: http.StatusOK, want: []string{"https://pay.staging.example.com", "https://search.example.com", "https://pay.example.com"}},
+		{name: "sort duration desc", query: "sort=duration&order=desc", expectedStatusCode: http.StatusOK, want: []string{"https://pay.staging.example.com", "https://search.example.com", "https://pay.example.com"}},
+		{name: "sort status", query: "sort=status", expectedStatusCode: http.StatusOK, want: []string{"https://pay.example.com", "https://search.example.com", "https://pay.staging.example.com"}},
+		{name: "invalid label", query: "label==payments", expectedStatusCode: http.StatusBadRequest},
+		{name: "invalid status", query: "status=abc", expectedStatusCode: http.StatusBadRequest},
+		{name: "invalid sort", query: "sort=name", expectedStatusCode: http.StatusBadRequest},
+		{name: "invalid order", query: "order=up", expectedStatusCode: http.StatusBadRequest},
+		{name: "invalid size", query: "size=1000", expectedStatusCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hh := &HealthCheckHandler{db: db}
+			w := httptest.NewRecorder()
+			serve(hh, w, httptest.NewRequest("GET", "/api/health/checks?"+tt.query, nil))
+
+			if tt.expectedStatusCode != w.Code {
+				t.Fatalf("got statuscode %d expected code %d", w.Code, tt.expectedStatusCode)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			got := models.HealthCheckList{}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			var endpoints []string
+			for _, hc := range got.Items {
+				endpoints = append(endpoints, hc.Endpoint)
+			}
+			if !reflect.DeepEqual(endpoints, tt.want) || got.Total != len(tt.want) {
+				t.Errorf("got %v (total %d), expected %v", endpoints, got.Total, tt.want)
+			}
+		})
+	}
+}
+
+func TestHealthCheckHandler_ListSize(t *testing.T) {
+	db := &mocks.FakeCollection{}
+	for _, endpoint := range []string{"a", "b", "c", "d", "e"} {
+		db.ListResp = append(db.ListResp, &models.HealthCheck{ID: endpoint, Endpoint: endpoint})
+	}
+	hh := &HealthCheckHandler{db: db}
+	w := httptest.NewRecorder()
+	serve(hh, w, httptest.NewRequest("GET", "/api/health/checks?page=1&size=2", nil))
+
+	got := models.HealthCheckList{}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 2 || got.Items[0].Endpoint != "c" || got.Size != 2 || got.Total != 5 || got.NextCursor != "" {
+		t.Errorf("unexpected page %s", w.Body.String())
+	}
+}
+
+func TestHealthCheckHandler_ListHugePage(t *testing.T) {
+	db := &mocks.FakeCollection{}
+	for _, endpoint := range []string{"a", "b", "c"} {
+		db.ListResp = append(db.ListResp, &models.HealthCheck{ID: endpoint, Endpoint: endpoint})
+	}
+	hh := &HealthCheckHandler{db: db}
+	w := httptest.NewRecorder()
+	serve(hh, w, httptest.NewRequest("GET", "/api/health/checks?page=92233720368547759&size=100", nil))
+
+	got := models.HealthCheckList{}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || len(got.Items) != 0 || got.Total != 3 {
+		t.Errorf("expected an empty page past the end, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHealthCheckHandler_ListCursor(t *testing.T) {
+	db := &mocks.FakeCollection{}
+	for i, endpoint := range []string{"a", "b", "c", "d", "e"} {
+		db.ListResp = append(db.ListResp, &models.HealthCheck{ID: endpoint, Endpoint: endpoint, Result: models.Result{Checked: int64(i)}})
+	}
+	hh := &HealthCheckHandler{db: db}
+
+	list := func(query string) (models.HealthCheckList, int) {
+		w := httptest.NewRecorder()
+		serve(hh, w, httptest.NewRequest("GET", "/api/health/checks?"+query, nil))
+		got := models.HealthCheckList{}
+		json.Unmarshal(w.Body.Bytes(), &got)
+		return got, w.Code
+	}
+
+	first, code := list("cursor=&sort=checked&size=2")
+	if code != http.StatusOK || len(first.Items) != 2 || first.Items[0].ID != "a" || first.NextCursor == "" || first.Total != 5 {
+		t.Fatalf("unexpected first page %+v, code %d", first, code)
+	}
+
+	// results change mid-scan, reversing the checked order, and a check is deleted
+	for i, hc := range db.ListResp {
+		db.Record(&models.HealthCheck{ID: hc.ID, Result: models.Result{Checked: int64(100 - i)}})
+	}
+	db.ListResp = append(db.ListResp[:2], db.ListResp[3:]...)
+
+	var ids []string
+	for _, hc := range first.Items {
+		ids = append(ids, hc.ID)
+	}
+	cursor := first.NextCursor
+	for cursor != "" {
+		page, code := list("cursor=" + cursor + "&size=2")
+		if code != http.StatusOK {
+			t.Fatalf("got statuscode %d expected code %d", code, http.StatusOK)
+		}
+		for _, hc := range page.Items {
+			ids = append(ids, hc.ID)
+		}
+		cursor = page.NextCursor
+	}
+	if want := []string{"a", "b", "d", "e"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("got %v, expected %v", ids, want)
+	}
+
+	// offsets past the end of an open scan are rejected
+	open, _ := list("cursor=&size=2")
+	token, _, err := decodeCursor(open.NextCursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pastEnd := []string{"cursor=" + encodeCursor(token, 5), "cursor=" + encodeCursor(token, math.MaxInt64-5)}
+
+	for _, query := range append([]string{"cursor=bm90LWEtY3Vyc29y", "cursor=" + first.NextCursor, "cursor=&page=1"}, pastEnd...) {
+		if _, code := list(query); code != http.StatusBadRequest {
+			t.Errorf("%s: got statuscode %d expected code %d", query, code, http.StatusBadRequest)
+		}
+	}
+}
+
 func TestHealthCheckHandler_Create(t *testing.T) {
 	type fields struct {
 		db healthCheckStorage
@@ -105,7 +321,7 @@ func TestHealthCheckHandler_Create(t *testing.T) {
 				db: &mocks.FakeCollection{},
 			},
 			payload:            `{"endpoint":  "https://www.blizzard.com/en-us/"}`,
-			expectedStatusCode: http.StatusOK,
+			expectedStatusCode: http.StatusCreated,
 		},
 		{
 			name: "empty endpoint",
@@ -131,6 +347,86 @@ func TestHealthCheckHandler_Create(t *testing.T) {
 			payload:            `{"endpoint":  "ww12bliz.it"}`,
 			expectedStatusCode: http.StatusBadRequest,
 		},
+		{
+			name: "post with headers and body",
+			fields: fields{
+				db: &mocks.FakeCollection{},
+			},
+			payload:            `{"endpoint": "https://www.blizzard.com/api", "method": "post", "headers": {"Authorization": "Bearer abc"}, "body": "{}"}`,
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name: "unsupported method",
+			fields: fields{
+				db: &mocks.FakeCollection{},
+			},
+			payload:            `{"endpoint": "https://www.blizzard.com/en-us/", "method": "CONNECT"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "invalid header name",
+			fields: fields{
+				db: &mocks.FakeCollection{},
+			},
+			payload:            `{"endpoint": "https://www.blizzard.com/en-us/", "headers": {"Bad Header": "x"}}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "body with get",
+			fields: fields{
+				db: &mocks.FakeCollection{},
+			},
+			payload:            `{"endpoint": "https://www.blizzard.com/en-us/", "body": "{}"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "expected status",
+			fields: fields{
+				db: &mocks.FakeCollection{},
+			},
+			payload:            `{"endpoint": "https://www.blizzard.com/en-us/", "expected_status": "200-299,301"}`,
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name: "invalid expected status",
+			fields: fields{
+				db: &mocks.FakeCollection{},
+			},
+			payload:            `{"endpoint": "https://www.blizzard.com/en-us/", "expected_status": "2xx"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "assertions",
+			fields: fields{
+				db: &mocks.FakeCollection{},
+			},
+			payload:            `{"endpoint": "https://www.blizzard.com/en-us/", "assertions": [{"type": "jsonpath", "path": "$.status", "value": "ok"}]}`,
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name: "invalid assertion",
+			fields: fields{
+				db: &mocks.FakeCollection{},
+			},
+			payload:            `{"endpoint": "https://www.blizzard.com/en-us/", "assertions": [{"type": "regex", "value": "("}]}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "tcp",
+			fields: fields{
+				db: &mocks.FakeCollection{},
+			},
+			payload:            `{"endpoint": "tcp://localhost:6379", "send": "PING\r\n", "expect": "+PONG"}`,
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name: "unsupported scheme",
+			fields: fields{
+				db: &mocks.FakeCollection{},
+			},
+			payload:            `{"endpoint": "ftp://localhost/file"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
 		{
 			name: "db error",
 			fields: fields{
@@ -141,6 +437,58 @@ func TestHealthCheckHandler_Create(t *testing.T) {
 			payload:            `{"endpoint":  "https://www.blizzard.com/en-us/"}`,
 			expectedStatusCode: http.StatusBadRequest,
 		},
+		{
+			name: "with labels",
+			fields: fields{
+				db: &mocks.FakeCollection{},
+			},
+			payload:            `{"endpoint": "https://www.blizzard.com/en-us/", "labels": {"team": "payments", "env": "production"}}`,
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name: "invalid label",
+			fields: fields{
+				db: &mocks.FakeCollection{},
+			},
+			payload:            `{"endpoint": "https://www.blizzard.com/en-us/", "labels": {"team": "a,b"}}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "composite",
+			fields: fields{
+				db: &mocks.FakeCollection{
+					ListResp: models.HealthChecks{&models.HealthCheck{ID: "us", Endpoint: "https://us.blizzard.com"}},
+				},
+			},
+			payload:            `{"endpoint": "composite://checkout", "members": ["us"], "member_selector": "service=checkout", "min_up": 1}`,
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name: "composite with unknown member",
+			fields: fields{
+				db: &mocks.FakeCollection{},
+			},
+			payload:            `{"endpoint": "composite://checkout", "members": ["us"]}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "with parent",
+			fields: fields{
+				db: &mocks.FakeCollection{
+					ListResp: models.HealthChecks{&models.HealthCheck{ID: "lb", Endpoint: "https://lb.blizzard.com"}},
+				},
+			},
+			payload:            `{"endpoint": "https://www.blizzard.com/en-us/", "parents": ["lb"]}`,
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name: "unknown parent",
+			fields: fields{
+				db: &mocks.FakeCollection{},
+			},
+			payload:            `{"endpoint": "https://www.blizzard.com/en-us/", "parents": ["lb"]}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -154,10 +502,294 @@ func TestHealthCheckHandler_Create(t *testing.T) {
 			if tt.expectedStatusCode != w.Code {
 				t.Errorf("got statuscode %d expected code %d", w.Code, tt.expectedStatusCode)
 			}
+			if w.Code == http.StatusCreated {
+				resp := &models.CreateHealthCheckResponse{}
+				json.Unmarshal(w.Body.Bytes(), resp)
+				if got := w.Header().Get("Location"); got != "/api/health/checks/"+resp.ID {
+					t.Errorf("got Location %q for id %s", got, resp.ID)
+				}
+			}
 		})
 	}
 }
 
+func TestHealthCheckHandler_BatchCreate(t *testing.T) {
+	tests := []struct {
+		name               string
+		url                string
+		payload            string
+		expectedStatusCode int
+		want               []string
+		wantCreated        int
+	}{
+		{
+			name: "mixed results",
+			url:  "/api/health/checks:batch",
+			payload: `[{"endpoint": "https://a.blizzard.com"}, {"endpoint": "https://www.blizzard.com"}, {"endpoint": ""},
+				{"endpoint": "https://b.blizzard.com", "expected_status": "2xx"}, {"endpoint": "https://a.blizzard.com"}, null]`,
+			expectedStatusCode: http.StatusOK,
+			want:               []string{models.BatchCreated, models.BatchDuplicate, models.BatchInvalid, models.BatchInvalid, models.BatchDuplicate, models.BatchInvalid},
+			wantCreated:        1,
+		},
+		{
+			name:               "dry run",
+			url:                "/api/health/checks:batch?dry_run=true",
+			payload:            `[{"endpoint": "https://a.blizzard.com"}, {"endpoint": "https://www.blizzard.com"}]`,
+			expectedStatusCode: http.StatusOK,
+			want:               []string{models.BatchCreated, models.BatchDuplicate},
+		},
+		{
+			name:               "not an array",
+			url:                "/api/health/checks:batch",
+			payload:            `{"endpoint": "https://a.blizzard.com"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "invalid dry run",
+			url:                "/api/health/checks:batch?dry_run=maybe",
+			payload:            `[]`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &mocks.FakeCollection{
+				ListResp: models.HealthChecks{&models.HealthCheck{ID: "www", Endpoint: "https://www.blizzard.com"}},
+			}
+			w := httptest.NewRecorder()
+			serve(&HealthCheckHandler{db: db}, w, httptest.NewRequest("POST", tt.url, strings.NewReader(tt.payload)))
+
+			if tt.expectedStatusCode != w.Code {
+				t.Fatalf("got statuscode %d expected code %d, body %s", w.Code, tt.expectedStatusCode, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			got := models.BatchResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			var results []string
+			for i, r := range got.Results {
+				results = append(results, r.Result)
+				if r.Index != i || (r.Result == models.BatchCreated && (r.ID == "") != got.DryRun) {
+					t.Errorf("unexpected result %+v", r)
+				}
+			}
+			if !reflect.DeepEqual(results, tt.want) || len(db.Created) != tt.wantCreated {
+				t.Errorf("got %v with %d created, expected %v with %d created", results, len(db.Created), tt.want, tt.wantCreated)
+			}
+		})
+	}
+}
+
+func TestHealthCheckHandler_BatchDelete(t *testing.T) {
+	const id = "C6C5B3DC-6685-7698-3CD5-C3AB7C10B3AC"
+	tests := []struct {
+		name      string
+		url       string
+		want      []string
+		remaining int
+	}{
+		{
+			name:      "mixed results",
+			url:       "/api/health/checks:batch",
+			want:      []string{models.BatchDeleted, models.BatchNotFound, models.BatchInvalid},
+			remaining: 0,
+		},
+		{
+			name:      "dry run",
+			url:       "/api/health/checks:batch?dry_run=true",
+			want:      []string{models.BatchDeleted, models.BatchNotFound, models.BatchInvalid},
+			remaining: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &mocks.FakeCollection{
+				ListResp: models.HealthChecks{&models.HealthCheck{ID: id, Endpoint: "https://www.blizzard.com"}},
+			}
+			payload := `["` + id + `", "00000000-0000-0000-0000-000000000000", "abc"]`
+			w := httptest.NewRecorder()
+			serve(&HealthCheckHandler{db: db}, w, httptest.NewRequest("DELETE", tt.url, strings.NewReader(payload)))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("got statuscode %d expected code %d, body %s", w.Code, http.StatusOK, w.Body.String())
+			}
+			got := models.BatchResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			var results []string
+			for _, r := range got.Results {
+				results = append(results, r.Result)
+			}
+			if !reflect.DeepEqual(results, tt.want) || len(db.List()) != tt.remaining {
+				t.Errorf("got %v with %d remaining, expected %v with %d remaining", results, len(db.List()), tt.want, tt.remaining)
+			}
+		})
+	}
+}
+
+func TestHealthCheckHandler_Update(t *testing.T) {
+	const id = "C6C5B3DC-6685-7698-3CD5-C3AB7C10B3AC"
+	const lb = "0A4D55A8-D778-4E9A-9C1A-AB4B5CF0C9A1"
+	tests := []struct {
+		name               string
+		method             string
+		id                 string
+		payload            string
+		updateErr          error
+		expectedStatusCode int
+		expected           *models.HealthCheck
+	}{
+		{
+			name:               "put replaces the configuration",
+			method:             http.MethodPut,
+			id:                 id,
+			payload:            `{"endpoint": "https://www.blizzard.com/api", "method": "post", "body": "{}"}`,
+			expectedStatusCode: http.StatusOK,
+			expected: &models.HealthCheck{ID: id, Endpoint: "https://www.blizzard.com/api", Method: "POST", Body: models.RedactedValue,
+				Result: models.Result{Checked: 100}},
+		},
+		{
+			name:               "patch only changes the sent fields",
+			method:             http.MethodPatch,
+			id:                 id,
+			payload:            `{"interval": "10s", "labels": {"env": "production"}}`,
+			expectedStatusCode: http.StatusOK,
+			expected: &models.HealthCheck{ID: id, Endpoint: "https://www.blizzard.com/en-us/", Interval: "10s",
+				Labels:  map[string]string{"team": "web", "env": "production"},
+				Headers: map[string]string{"Authorization": models.RedactedValue, "Accept": models.RedactedValue}, Result: models.Result{Checked: 100}},
+		},
+		{
+			name:               "patch null removes a label",
+			method:             http.MethodPatch,
+			id:                 id,
+			payload:            `{"labels": {"team": null, "env": "production"}, "headers": {"Authorization": null}}`,
+			expectedStatusCode: http.StatusOK,
+			expected: &models.HealthCheck{ID: id, Endpoint: "https://www.blizzard.com/en-us/",
+				Labels: map[string]string{"env": "production"}, Headers: map[string]string{"Accept": models.RedactedValue}, Result: models.Result{Checked: 100}},
+		},
+		{
+			name:               "patch null resets a field",
+			method:             http.MethodPatch,
+			id:                 id,
+			payload:            `{"labels": null, "headers": null}`,
+			expectedStatusCode: http.StatusOK,
+			expected:           &models.HealthCheck{ID: id, Endpoint: "https://www.blizzard.com/en-us/", Result: models.Result{Checked: 100}},
+		},
+		{
+			name:               "patch with the wrong type",
+			method:             http.MethodPatch,
+			id:                 id,
+			payload:            `{"labels": {"team": 1}}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "not found",
+			method:             http.MethodPut,
+			id:                 "00000000-0000-0000-0000-000000000000",
+			payload:            `{"endpoint": "https://www.blizzard.com/en-us/"}`,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "marshall err",
+			method:             http.MethodPatch,
+			id:                 id,
+			payload:            `<<<`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "put without endpoint",
+			method:             http.MethodPut,
+			id:                 id,
+			payload:            `{"interval": "10s"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "invalid config",
+			method:             http.MethodPatch,
+			id:                 id,
+			payload:            `{"expected_status": "2xx"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "dependency cycle",
+			method:             http.MethodPatch,
+			id:                 id,
+			payload:            `{"parents": ["` + lb + `"]}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "duplicate endpoint",
+			method:             http.MethodPatch,
+			id:                 id,
+			payload:            `{"endpoint": "https://lb.blizzard.com"}`,
+			updateErr:          errors.New("endpoint https://lb.blizzard.com already registered"),
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &mocks.FakeCollection{
+				ListResp: models.HealthChecks{
+					&models.HealthCheck{ID: id, Endpoint: "https://www.blizzard.com/en-us/", Labels: map[string]string{"team": "web"},
+						Headers: map[string]string{"Authorization": "Bearer t0ken", "Accept": "text/html"}, Result: models.Result{Checked: 100}},
+					&models.HealthCheck{ID: lb, Endpoint: "https://lb.blizzard.com", Parents: []string{id}},
+				},
+				UpdateErr: tt.updateErr,
+			}
+			hh := &HealthCheckHandler{db: db}
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, "/api/health/checks/"+tt.id, strings.NewReader(tt.payload))
+			serve(hh, w, req)
+
+			if tt.expectedStatusCode != w.Code {
+				t.Fatalf("got statuscode %d expected code %d, body %s", w.Code, tt.expectedStatusCode, w.Body.String())
+			}
+			if tt.expected == nil {
+				return
+			}
+			got := &models.HealthCheck{}
+			if err := json.Unmarshal(w.Body.Bytes(), got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %+v expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHealthCheckHandler_Redacted(t *testing.T) {
+	const id = "C6C5B3DC-6685-7698-3CD5-C3AB7C10B3AC"
+	db := &mocks.FakeCollection{
+		ListResp: models.HealthChecks{
+			&models.HealthCheck{ID: id, Endpoint: "https://www.blizzard.com/api", Method: "POST", Body: `{"key":"s3cret"}`,
+				Headers: map[string]string{"Authorization": "Bearer t0ken"}},
+		},
+	}
+	hh := &HealthCheckHandler{db: db}
+
+	w := httptest.NewRecorder()
+	serve(hh, w, httptest.NewRequest("GET", "/api/health/checks/"+id, nil))
+	if strings.Contains(w.Body.String(), "t0ken") || strings.Contains(w.Body.String(), "s3cret") {
+		t.Fatalf("expected headers and body to be redacted, got %s", w.Body.String())
+	}
+
+	// putting back what was read keeps the stored values
+	put := httptest.NewRecorder()
+	serve(hh, put, httptest.NewRequest("PUT", "/api/health/checks/"+id, strings.NewReader(w.Body.String())))
+	if put.Code != http.StatusOK {
+		t.Fatalf("got statuscode %d, body %s", put.Code, put.Body.String())
+	}
+	stored := db.ListResp[0]
+	if stored.Headers["Authorization"] != "Bearer t0ken" || stored.Body != `{"key":"s3cret"}` {
+		t.Errorf("expected the redacted values to be kept, got headers %v body %s", stored.Headers, stored.Body)
+	}
+}
+
 func TestHealthCheckHandler_Execute(t *testing.T) {
 	type fields struct {
 		db healthCheckStorage
@@ -188,8 +820,7 @@ func TestHealthCheckHandler_Execute(t *testing.T) {
 				db: tt.fields.db,
 			}
 			w := httptest.NewRecorder()
-			req := httptest.NewRequest("POST", tt.url, nil)
-			hh.Execute(w, req)
+			serve(hh, w, httptest.NewRequest("POST", tt.url, nil))
 
 			if tt.expectedStatusCode != w.Code {
 				t.Errorf("got statuscode %d expected code %d", w.Code, tt.expectedStatusCode)
@@ -197,3 +828,163 @@ func TestHealthCheckHandler_Execute(t *testing.T) {
 		})
 	}
 }
+
+func TestHealthCheckHandler_History(t *testing.T) {
+	results := []models.Result{{Checked: 3}, {Checked: 2}, {Checked: 1}}
+	tests := []struct {
+		name               string
+		db                 healthCheckStorage
+		url                string
+		expectedStatusCode int
+		want               models.HistoryList
+	}{
+		{
+			name:               "success",
+			db:                 &mocks.FakeCollection{HistoryResp: results},
+			url:                "/api/health/checks/C6C5B3DC-6685-7698-3CD5-C3AB7C10B3AC/history?page=1&size=2",
+			expectedStatusCode: http.StatusOK,
+			want: models.HistoryList{
+				Items: []models.Result{{Checked: 1}},
+				Page:  1,
+				Total: 3,
+				Size:  2,
+			},
+		},
+		{
+			name:               "time range",
+			db:                 &mocks.FakeCollection{HistoryResp: results},
+			url:                "/api/health/checks/C6C5B3DC-6685-7698-3CD5-C3AB7C10B3AC/history?from=2019-11-28T00:00:00Z&to=1574906993",
+			expectedStatusCode: http.StatusOK,
+			want: models.HistoryList{
+				Items: results,
+				Page:  0,
+				Total: 3,
+				Size:  10,
+			},
+		},
+		{
+			name:               "invalid time",
+			db:                 &mocks.FakeCollection{},
+			url:                "/api/health/checks/C6C5B3DC-6685-7698-3CD5-C3AB7C10B3AC/history?from=yesterday",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "page past the end",
+			db:                 &mocks.FakeCollection{HistoryResp: results},
+			url:                "/api/health/checks/C6C5B3DC-6685-7698-3CD5-C3AB7C10B3AC/history?page=92233720368547759&size=100",
+			expectedStatusCode: http.StatusOK,
+			want: models.HistoryList{
+				Items: []models.Result{},
+				Page:  92233720368547759,
+				Total: 3,
+				Size:  100,
+			},
+		},
+		{
+			name:               "invalid size",
+			db:                 &mocks.FakeCollection{},
+			url:                "/api/health/checks/C6C5B3DC-6685-7698-3CD5-C3AB7C10B3AC/history?size=1000",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "not found",
+			db:                 &mocks.FakeCollection{HistoryErr: errors.New("not found")},
+			url:                "/api/health/checks/C6C5B3DC-6685-7698-3CD5-C3AB7C10B3AC/history",
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hh := &HealthCheckHandler{
+				db: tt.db,
+			}
+			w := httptest.NewRecorder()
+			serve(hh, w, httptest.NewRequest("GET", tt.url, nil))
+
+			if tt.expectedStatusCode != w.Code {
+				t.Errorf("got statuscode %d expected code %d", w.Code, tt.expectedStatusCode)
+				return
+			}
+
+			if tt.expectedStatusCode == http.StatusOK {
+				expected, _ := json.Marshal(tt.want)
+				if !reflect.DeepEqual(w.Body.Bytes(), expected) {
+					t.Errorf("expected to be equal \n%s\n%s", w.Body.String(), string(expected))
+				}
+			}
+		})
+	}
+}
+
+func TestHealthCheckHandler_Stats(t *testing.T) {
+	now := time.Now().Unix()
+	tests := []struct {
+		name               string
+		db                 healthCheckStorage
+		url                string
+		expectedStatusCode int
+		wantChecks         int
+	}{
+		{
+			name: "success",
+			db: &mocks.FakeCollection{
+				HistoryResp: []models.Result{
+					{Checked: now, Healthy: true, DurationMs: 10},
+					{Checked: now, Healthy: false},
+				},
+			},
+			url:                "/api/health/checks/C6C5B3DC-6685-7698-3CD5-C3AB7C10B3AC/stats",
+			expectedStatusCode: http.StatusOK,
+			wantChecks:         2,
+		},
+		{
+			name:               "not found",
+			db:                 &mocks.FakeCollection{HistoryErr: errors.New("not found")},
+			url:                "/api/health/checks/C6C5B3DC-6685-7698-3CD5-C3AB7C10B3AC/stats",
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hh := &HealthCheckHandler{
+				db: tt.db,
+			}
+			w := httptest.NewRecorder()
+			serve(hh, w, httptest.NewRequest("GET", tt.url, nil))
+
+			if tt.expectedStatusCode != w.Code {
+				t.Errorf("got statuscode %d expected code %d", w.Code, tt.expectedStatusCode)
+				return
+			}
+
+			if tt.expectedStatusCode == http.StatusOK {
+				res := models.HealthCheckStats{}
+				if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+					t.Fatal(err)
+				}
+				if len(res.Stats) == 0 || res.Stats[0].Checks != tt.wantChecks || *res.Stats[0].Uptime != 50 {
+					t.Errorf("unexpected stats %s", w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestHealthCheckHandler_ListStats(t *testing.T) {
+	hh := &HealthCheckHandler{
+		db: &mocks.FakeCollection{
+			ListResp:    models.HealthChecks{&models.HealthCheck{ID: "a", Endpoint: "a"}},
+			HistoryResp: []models.Result{{Checked: time.Now().Unix(), Healthy: true, DurationMs: 10}},
+		},
+	}
+	w := httptest.NewRecorder()
+	hh.List(w, httptest.NewRequest("GET", "/api/health/checks?page=0&stats=true", nil))
+
+	res := models.HealthCheckList{}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Items) != 1 || len(res.Items[0].Stats) == 0 {
+		t.Errorf("expected stats to be inlined, got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/dnguy078/healthcheck/pkg/maintenance"
+	"github.com/dnguy078/healthcheck/pkg/models"
+	"github.com/dnguy078/healthcheck/pkg/utils"
+)
+
+// MaintenanceHandler serves CRUD endpoints for maintenance windows
+type MaintenanceHandler struct {
+	db maintenanceStorage
+}
+
+type maintenanceStorage interface {
+	Get(id string) (*models.HealthCheck, error)
+	ListMaintenance() []*models.MaintenanceWindow
+	GetMaintenance(id string) (*models.MaintenanceWindow, error)
+	CreateMaintenance(mw *models.MaintenanceWindow) error
+	UpdateMaintenance(mw *models.MaintenanceWindow) error
+	DeleteMaintenance(id string) error
+}
+
+// Routes registers the maintenance window endpoints
+func (mh *MaintenanceHandler) Routes(rt *Router) {
+	rt.Handle("/api/maintenance", mh.List, http.MethodGet)
+	rt.Handle("/api/maintenance", mh.Create, http.MethodPost)
+	rt.Handle("/api/maintenance/{id}", mh.Get, http.MethodGet)
+	rt.Handle("/api/maintenance/{id}", mh.Update, http.MethodPut)
+	rt.Handle("/api/maintenance/{id}", mh.Delete, http.MethodDelete)
+}
+
+// List returns every maintenance window
+func (mh *MaintenanceHandler) List(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, mh.db.ListMaintenance(), http.StatusOK)
+}
+
+// Get returns a specific maintenance window
+func (mh *MaintenanceHandler) Get(w http.ResponseWriter, r *http.Request) {
+	mw, err := mh.db.GetMaintenance(pathID(r))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, mw, http.StatusOK)
+}
+
+// Create adds a maintenance window
+func (mh *MaintenanceHandler) Create(w http.ResponseWriter, r *http.Request) {
+	mw, ok := mh.decode(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := utils.UUID()
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	mw.ID = id
+
+	if err := mh.db.CreateMaintenance(mw); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Location", "/api/maintenance/"+mw.ID)
+	writeJSON(w, mw, http.StatusCreated)
+}
+
+// Update replaces a maintenance window
+func (mh *MaintenanceHandler) Update(w http.ResponseWriter, r *http.Request) {
+	mw, ok := mh.decode(w, r)
+	if !ok {
+		return
+	}
+	mw.ID = pathID(r)
+
+	if err := mh.db.UpdateMaintenance(mw); err != nil {
+		writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, mw, http.StatusOK)
+}
+
+// Delete removes a maintenance window
+func (mh *MaintenanceHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := mh.db.DeleteMaintenance(pathID(r)); err != nil {
+		writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decode reads and validates a maintenance window from the request body, writing an error response if it
+// is invalid
+func (mh *MaintenanceHandler) decode(w http.ResponseWriter, r *http.Request) (*models.MaintenanceWindow, bool) {
+	mw := &models.MaintenanceWindow{}
+	if err := json.NewDecoder(r.Body).Decode(mw); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	if err := maintenance.Validate(mw); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	for _, id := range mw.CheckIDs {
+		if _, err := mh.db.Get(id); err != nil {
+			writeError(w, fmt.Sprintf("unknown check id %s", id), http.StatusBadRequest)
+			return nil, false
+		}
+	}
+	return mw, true
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+	"github.com/dnguy078/healthcheck/pkg/storage/mocks"
+)
+
+const testWindowID = "A1B2C3D4-6685-7698-3CD5-C3AB7C10B3AC"
+
+func newMaintenanceDB() *mocks.FakeCollection {
+	return &mocks.FakeCollection{
+		ListResp: models.HealthChecks{
+			&models.HealthCheck{ID: "C6C5B3DC-6685-7698-3CD5-C3AB7C10B3AC", Endpoint: "https://www.blizzard.com"},
+		},
+		Maintenance: map[string]*models.MaintenanceWindow{
+			testWindowID: {ID: testWindowID, Selector: "role=db", Mode: models.MaintenanceSkip, Start: 100, End: 200},
+		},
+	}
+}
+
+func TestMaintenanceHandler(t *testing.T) {
+	tests := []struct {
+		name               string
+		method             string
+		url                string
+		body               string
+		expectedStatusCode int
+		wantWindows        int
+		wantMode           string
+	}{
+		{name: "list", method: "GET", url: "/api/maintenance", expectedStatusCode: http.StatusOK, wantWindows: 1},
+		{name: "get", method: "GET", url: "/api/maintenance/" + testWindowID, expectedStatusCode: http.StatusOK, wantWindows: 1, wantMode: models.MaintenanceSkip},
+		{name: "get unknown", method: "GET", url: "/api/maintenance/B1B2C3D4-6685-7698-3CD5-C3AB7C10B3AC", expectedStatusCode: http.StatusNotFound, wantWindows: 1},
+		{
+			name:               "create one-off",
+			method:             "POST",
+			url:                "/api/maintenance",
+			body:               `{"check_ids": ["C6C5B3DC-6685-7698-3CD5-C3AB7C10B3AC"], "mode": "suppress", "start": 1574906993, "end": 1574910593}`,
+			expectedStatusCode: http.StatusCreated,
+			wantWindows:        2,
+			wantMode:           models.MaintenanceSuppress,
+		},
+		{
+			name:               "create recurring",
+			method:             "POST",
+			url:                "/api/maintenance",
+			body:               `{"selector": "role=db", "cron": "0 2 * * 0", "duration": "2h"}`,
+			expectedStatusCode: http.StatusCreated,
+			wantWindows:        2,
+			wantMode:           models.MaintenanceSkip,
+		},
+		{
+			name:               "create with unknown check",
+			method:             "POST",
+			url:                "/api/maintenance",
+			body:               `{"check_ids": ["unknown"], "start": 1574906993, "end": 1574910593}`,
+			expectedStatusCode: http.StatusBadRequest,
+			wantWindows:        1,
+		},
+		{
+			name:               "create invalid cron",
+			method:             "POST",
+			url:                "/api/maintenance",
+			body:               `{"selector": "role=db", "cron": "0 2 * *", "duration": "2h"}`,
+			expectedStatusCode: http.StatusBadRequest,
+			wantWindows:        1,
+		},
+		{
+			name:               "update",
+			method:             "PUT",
+			url:                "/api/maintenance/" + testWindowID,
+			body:               `{"selector": "role=db", "mode": "suppress", "start": 100, "end": 300}`,
+			expectedStatusCode: http.StatusOK,
+			wantWindows:        1,
+			wantMode:           models.MaintenanceSuppress,
+		},
+		{
+			name:               "update unknown",
+			method:             "PUT",
+			url:                "/api/maintenance/B1B2C3D4-6685-7698-3CD5-C3AB7C10B3AC",
+			body:               `{"selector": "role=db", "start": 100, "end": 300}`,
+			expectedStatusCode: http.StatusNotFound,
+			wantWindows:        1,
+		},
+		{name: "delete", method: "DELETE", url: "/api/maintenance/" + testWindowID, expectedStatusCode: http.StatusNoContent},
+		{name: "delete unknown", method: "DELETE", url: "/api/maintenance/B1B2C3D4-6685-7698-3CD5-C3AB7C10B3AC", expectedStatusCode: http.StatusNotFound, wantWindows: 1},
+		{name: "method not allowed", method: "PUT", url: "/api/maintenance", expectedStatusCode: http.StatusMethodNotAllowed, wantWindows: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newMaintenanceDB()
+			mh := &MaintenanceHandler{db}
+			w := httptest.NewRecorder()
+			serve(mh, w, httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body)))
+
+			if w.Code != tt.expectedStatusCode {
+				t.Fatalf("got statuscode %d expected code %d, body: %s", w.Code, tt.expectedStatusCode, w.Body.String())
+			}
+			if got := len(db.ListMaintenance()); got != tt.wantWindows {
+				t.Errorf("got %d windows, expected %d", got, tt.wantWindows)
+			}
+			if tt.wantMode != "" {
+				got := models.MaintenanceWindow{}
+				if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || got.ID == "" || got.Mode != tt.wantMode {
+					t.Errorf("unexpected window %s", w.Body.String())
+				}
+			}
+		})
+	}
+}
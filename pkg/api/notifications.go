@@ -0,0 +1,33 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dnguy078/healthcheck/pkg/notify"
+)
+
+// NotificationHandler serves the notification delivery log
+type NotificationHandler struct {
+	deliveries deliveryLog
+}
+
+type deliveryLog interface {
+	List() []notify.Delivery
+}
+
+// Routes registers the notification endpoints
+func (nh *NotificationHandler) Routes(rt *Router) {
+	rt.Handle("/api/notifications/deliveries", nh.Deliveries, http.MethodGet)
+}
+
+// Deliveries returns the most recent notification deliveries, newest first
+func (nh *NotificationHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	b, err := json.Marshal(nh.deliveries.List())
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(b)
+}
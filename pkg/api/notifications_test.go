@@ -0,0 +1,27 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dnguy078/healthcheck/pkg/notify"
+)
+
+func TestNotificationHandler_Deliveries(t *testing.T) {
+	deliveries := notify.NewDeliveryLog(10)
+	deliveries.Add(notify.Delivery{Notifier: "ops", CheckID: "a", Success: true})
+
+	nh := &NotificationHandler{deliveries}
+	w := httptest.NewRecorder()
+	serve(nh, w, httptest.NewRequest("GET", "/api/notifications/deliveries", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("got statuscode %d expected code %d", w.Code, http.StatusOK)
+	}
+	got := []notify.Delivery{}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || len(got) != 1 || got[0].Notifier != "ops" {
+		t.Errorf("unexpected deliveries %s", w.Body.String())
+	}
+}
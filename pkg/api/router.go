@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/dnguy078/healthcheck/pkg/utils"
+)
+
+// idParam is the pattern segment matching a uuid, ie) /api/health/checks/{id}
+const idParam = "{id}"
+
+// idKey is the request context key of the uuid matched by {id}
+type idKey struct{}
+
+// Router routes requests by method and path pattern. Patterns are matched segment by segment, literal
+// segments must match exactly and {id} matches a single uuid. Every response is JSON, unknown paths are a 404
+// and known paths with an unregistered method are a 405 listing the allowed methods. Handlers read the
+// matched {id} with pathID
+type Router struct {
+	routes []*route
+}
+
+type route struct {
+	method   string
+	segments []string
+	handler  http.HandlerFunc
+}
+
+// NewRouter returns an empty router
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Handle registers a handler for a path pattern and each of the methods
+func (rt *Router) Handle(pattern string, handler http.HandlerFunc, methods ...string) {
+	for _, method := range methods {
+		rt.routes = append(rt.routes, &route{
+			method:   method,
+			segments: splitPath(pattern),
+			handler:  handler,
+		})
+	}
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	segments := splitPath(r.URL.Path)
+	var allowed []string
+	for _, route := range rt.routes {
+		id, ok := route.match(segments)
+		if !ok {
+			continue
+		}
+		if route.method == r.Method {
+			if id != "" {
+				r = r.WithContext(context.WithValue(r.Context(), idKey{}, id))
+			}
+			route.handler(w, r)
+			return
+		}
+		allowed = append(allowed, route.method)
+	}
+
+	if len(allowed) == 0 {
+		writeError(w, "not found", http.StatusNotFound)
+		return
+	}
+	sort.Strings(allowed)
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+}
+
+// match returns whether the path segments match the route's pattern and the segment matched by {id}
+func (rt *route) match(segments []string) (string, bool) {
+	if len(segments) != len(rt.segments) {
+		return "", false
+	}
+	var id string
+	for i, s := range rt.segments {
+		if s == idParam {
+			if !utils.IsUUID(segments[i]) {
+				return "", false
+			}
+			id = segments[i]
+			continue
+		}
+		if s != segments[i] {
+			return "", false
+		}
+	}
+	return id, true
+}
+
+// pathID returns the uuid the router matched for the {id} segment of the request's path
+func pathID(r *http.Request) string {
+	id, _ := r.Context().Value(idKey{}).(string)
+	return id
+}
+
+// splitPath splits a path into its segments, ignoring a trailing slash
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, msg string, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	w.Write([]byte(marshalError(msg)))
+}
+
+// writeJSON writes v as a JSON response with the status code, marshalling it first so a failure can still
+// be written as an error
+func writeJSON(w http.ResponseWriter, v interface{}, code int) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(code)
+	w.Write(b)
+}
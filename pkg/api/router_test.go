@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+	"github.com/dnguy078/healthcheck/pkg/storage/mocks"
+)
+
+// serve routes a request to a handler's endpoints
+func serve(h interface{ Routes(*Router) }, w http.ResponseWriter, r *http.Request) {
+	rt := NewRouter()
+	h.Routes(rt)
+	rt.ServeHTTP(w, r)
+}
+
+func TestRouter(t *testing.T) {
+	const id = "C6C5B3DC-6685-7698-3CD5-C3AB7C10B3AC"
+	tests := []struct {
+		name               string
+		method             string
+		url                string
+		expectedStatusCode int
+		expectedAllow      string
+	}{
+		{name: "list", method: "GET", url: "/api/health/checks", expectedStatusCode: http.StatusOK},
+		{name: "list trailing slash", method: "GET", url: "/api/health/checks/", expectedStatusCode: http.StatusOK},
+		{name: "get", method: "GET", url: "/api/health/checks/" + id, expectedStatusCode: http.StatusOK},
+		{name: "get unknown", method: "GET", url: "/api/health/checks/00000000-0000-0000-0000-000000000000", expectedStatusCode: http.StatusNotFound},
+		{name: "get invalid id", method: "GET", url: "/api/health/checks/abc", expectedStatusCode: http.StatusNotFound},
+		{name: "unknown path", method: "GET", url: "/api/health/checks/" + id + "/unknown", expectedStatusCode: http.StatusNotFound},
+		{name: "try unknown", method: "POST", url: "/api/health/checks/00000000-0000-0000-0000-000000000000/try", expectedStatusCode: http.StatusNotFound},
+		{name: "get try", method: "GET", url: "/api/health/checks/" + id + "/try", expectedStatusCode: http.StatusMethodNotAllowed, expectedAllow: "POST"},
+		{name: "delete list", method: "DELETE", url: "/api/health/checks", expectedStatusCode: http.StatusMethodNotAllowed, expectedAllow: "GET, POST"},
+		{name: "post check", method: "POST", url: "/api/health/checks/" + id, expectedStatusCode: http.StatusMethodNotAllowed, expectedAllow: "DELETE, GET, PATCH, PUT"},
+		{name: "delete", method: "DELETE", url: "/api/health/checks/" + id, expectedStatusCode: http.StatusNoContent},
+		{name: "delete unknown", method: "DELETE", url: "/api/health/checks/00000000-0000-0000-0000-000000000000", expectedStatusCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &mocks.FakeCollection{
+				ListResp: models.HealthChecks{&models.HealthCheck{ID: id, Endpoint: "https://www.blizzard.com"}},
+			}
+			if tt.method == "DELETE" && tt.url != "/api/health/checks/"+id {
+				db.DeleteErr = errors.New("not found")
+			}
+			w := httptest.NewRecorder()
+			serve(&HealthCheckHandler{db: db}, w, httptest.NewRequest(tt.method, tt.url, nil))
+
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("got statuscode %d expected code %d", w.Code, tt.expectedStatusCode)
+			}
+			if got := w.Header().Get("Allow"); got != tt.expectedAllow {
+				t.Errorf("got Allow %q expected %q", got, tt.expectedAllow)
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("got Content-Type %q expected application/json", got)
+			}
+		})
+	}
+}
+
+func TestRouter_PathID(t *testing.T) {
+	const id = "C6C5B3DC-6685-7698-3CD5-C3AB7C10B3AC"
+	var got string
+	rt := NewRouter()
+	rt.Handle("/api/health/checks/{id}/history", func(w http.ResponseWriter, r *http.Request) {
+		got = pathID(r)
+	}, http.MethodGet)
+
+	rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/health/checks/"+id+"/history", nil))
+	if got != id {
+		t.Errorf("got id %q expected %q", got, id)
+	}
+}
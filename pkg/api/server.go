@@ -5,13 +5,14 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/dnguy078/healthcheck/pkg/notify"
 	"github.com/dnguy078/healthcheck/pkg/storage"
 )
 
 // Server is a http server
 type Server struct {
 	httpServer *http.Server
-	router     *http.ServeMux
+	router     *Router
 	addr       string
 	sslCert    string
 	sslKey     string
@@ -19,14 +20,17 @@ type Server struct {
 }
 
 // NewServer returns a http server
-func NewServer(addr string, sslCert string, sslKey string, db *storage.Collection) (*Server, error) {
-	router := http.NewServeMux()
+func NewServer(addr string, sslCert string, sslKey string, db *storage.Collection, deliveries *notify.DeliveryLog) (*Server, error) {
+	router := NewRouter()
 	httpServer := &http.Server{Addr: addr, Handler: router}
 
-	hh := &HealthCheckHandler{db}
+	hh := &HealthCheckHandler{db: db}
+	nh := &NotificationHandler{deliveries}
+	mh := &MaintenanceHandler{db}
 
-	router.Handle("/api/health/checks/", hh)
-	router.Handle("/api/health/checks", hh)
+	hh.Routes(router)
+	nh.Routes(router)
+	mh.Routes(router)
 
 	return &Server{
 		router:     router,
@@ -0,0 +1,270 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+	"github.com/dnguy078/healthcheck/pkg/service"
+	"gopkg.in/yaml.v2"
+)
+
+// change actions
+const (
+	Created = "created"
+	Updated = "updated"
+	Deleted = "deleted"
+)
+
+// File lists the healthchecks managed by the config file, each in the same format as the create api
+type File struct {
+	Checks []*models.CreateHealthCheckRequest `json:"checks" yaml:"checks"`
+}
+
+// Change is a healthcheck the reconcile created, updated or deleted. Fields lists the config fields an
+// update changed
+type Change struct {
+	Action   string
+	ID       string
+	Endpoint string
+	Fields   []FieldChange
+}
+
+// FieldChange is a config field an update changed, with its old and new values as JSON
+type FieldChange struct {
+	Name string
+	Old  string
+	New  string
+}
+
+func (c Change) String() string {
+	s := fmt.Sprintf("%s %s (%s)", c.Action, c.Endpoint, c.ID)
+	if len(c.Fields) > 0 {
+		diffs := make([]string, 0, len(c.Fields))
+		for _, f := range c.Fields {
+			diffs = append(diffs, fmt.Sprintf("%s %s -> %s", f.Name, f.Old, f.New))
+		}
+		s += ": " + strings.Join(diffs, ", ")
+	}
+	return s
+}
+
+type checkStorage interface {
+	List() models.HealthChecks
+	Create(*models.HealthCheck) error
+	Update(*models.HealthCheck) error
+	Delete(id string) error
+}
+
+// Load reads a config file, .json files are parsed as JSON and anything else as YAML. Unknown fields are
+// rejected so typos are not silently ignored
+func Load(filePath string) (*File, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadFile(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{}
+	if ext := strings.ToLower(filepath.Ext(absPath)); ext == ".json" {
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(f)
+	} else {
+		// YAML is decoded straight into the request so scalars like 200 or yes keep their text in
+		// string fields
+		err = yaml.UnmarshalStrict(b, f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+	return f, nil
+}
+
+// Reconcile makes the healthchecks managed by the config file match it. Checks are matched by endpoint,
+// missing ones are created, changed ones are updated in place keeping their id and history, and ones no
+// longer in the file are deleted. Healthchecks created through the api are never changed. Parents and
+// members can be listed by endpoint, so checks in the file can refer to each other before they have ids.
+// The whole file is validated against the checks as they will be once it is applied, nothing is changed
+// if any check is invalid or its endpoint is already used by an api created healthcheck
+func Reconcile(db checkStorage, f *File) ([]Change, error) {
+	managed := map[string]*models.HealthCheck{}
+	var final models.HealthChecks
+	for _, hc := range db.List() {
+		if hc.Source == models.SourceConfig {
+			managed[hc.Endpoint] = hc
+			continue
+		}
+		final = append(final, hc)
+	}
+	others := make(map[string]bool, len(final))
+	for _, hc := range final {
+		others[hc.Endpoint] = true
+	}
+
+	planned := make([]*models.HealthCheck, 0, len(f.Checks))
+	seen := map[string]bool{}
+	for i, req := range f.Checks {
+		if req == nil {
+			return nil, fmt.Errorf("check %d: empty check", i)
+		}
+		if err := service.ValidateEndpoint(req.Endpoint); err != nil {
+			return nil, fmt.Errorf("check %d: %v", i, err)
+		}
+		if seen[req.Endpoint] {
+			return nil, fmt.Errorf("check %d: endpoint %s is listed more than once", i, req.Endpoint)
+		}
+		seen[req.Endpoint] = true
+		if others[req.Endpoint] {
+			return nil, fmt.Errorf("check %d: endpoint %s is already registered through the api", i, req.Endpoint)
+		}
+
+		var hc *models.HealthCheck
+		if existing, ok := managed[req.Endpoint]; ok {
+			hc = existing.Copy()
+		} else {
+			var err error
+			if hc, err = models.NewHealthCheck(req.Endpoint); err != nil {
+				return nil, err
+			}
+			hc.Source = models.SourceConfig
+		}
+		req.Apply(hc)
+		planned = append(planned, hc)
+		final = append(final, hc)
+	}
+
+	// references are resolved once every check has an id, then validated against the final list so
+	// checks can depend on ones created by the same file but not on ones it deletes
+	ids := make(map[string]string, len(final))
+	for _, hc := range final {
+		ids[hc.Endpoint] = hc.ID
+	}
+	for _, hc := range planned {
+		hc.Parents = resolve(hc.Parents, ids)
+		hc.Members = resolve(hc.Members, ids)
+	}
+
+	var creates, updates []*models.HealthCheck
+	var created, updated []Change
+	for i, hc := range planned {
+		if err := service.Validate(hc); err != nil {
+			return nil, fmt.Errorf("check %d: %v", i, err)
+		}
+		if err := service.ValidateParents(hc, final); err != nil {
+			return nil, fmt.Errorf("check %d: %v", i, err)
+		}
+		if err := service.ValidateMembers(hc, final); err != nil {
+			return nil, fmt.Errorf("check %d: %v", i, err)
+		}
+
+		existing, ok := managed[hc.Endpoint]
+		if !ok {
+			creates = append(creates, hc)
+			created = append(created, Change{Action: Created, ID: hc.ID, Endpoint: hc.Endpoint})
+			continue
+		}
+		if fields := changedFields(existing.Request(), hc.Request()); len(fields) > 0 {
+			updates = append(updates, hc)
+			updated = append(updated, Change{Action: Updated, ID: hc.ID, Endpoint: hc.Endpoint, Fields: fields})
+		}
+	}
+
+	var deletes []*models.HealthCheck
+	for endpoint, hc := range managed {
+		if !seen[endpoint] {
+			deletes = append(deletes, hc)
+		}
+	}
+	sort.Sort(models.HealthChecks(deletes))
+
+	// deletes go first to release their endpoints, the changes applied before an error are returned with it
+	var applied []Change
+	for _, hc := range deletes {
+		if err := db.Delete(hc.ID); err != nil {
+			return applied, err
+		}
+		applied = append(applied, Change{Action: Deleted, ID: hc.ID, Endpoint: hc.Endpoint})
+	}
+	for i, hc := range updates {
+		if err := db.Update(hc); err != nil {
+			return applied, err
+		}
+		applied = append(applied, updated[i])
+	}
+	for i, hc := range creates {
+		if err := db.Create(hc); err != nil {
+			return applied, err
+		}
+		applied = append(applied, created[i])
+	}
+	return applied, nil
+}
+
+// resolve replaces the endpoints in a list of parents or members with their check's id, anything else is
+// kept as an id
+func resolve(refs []string, ids map[string]string) []string {
+	if refs == nil {
+		return nil
+	}
+	resolved := make([]string, len(refs))
+	for i, ref := range refs {
+		resolved[i] = ref
+		if id, ok := ids[ref]; ok {
+			resolved[i] = id
+		}
+	}
+	return resolved
+}
+
+// changedFields returns the fields that differ between two requests, sorted by their json name
+func changedFields(a, b *models.CreateHealthCheckRequest) []FieldChange {
+	va, vb := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem()
+	var fields []FieldChange
+	for i := 0; i < va.NumField(); i++ {
+		x, y := va.Field(i), vb.Field(i)
+		if isEmpty(x) && isEmpty(y) {
+			continue
+		}
+		if !reflect.DeepEqual(x.Interface(), y.Interface()) {
+			fields = append(fields, FieldChange{
+				Name: strings.Split(va.Type().Field(i).Tag.Get("json"), ",")[0],
+				Old:  jsonString(x),
+				New:  jsonString(y),
+			})
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields
+}
+
+// jsonString returns a field's value as JSON, null if it is empty
+func jsonString(v reflect.Value) string {
+	if isEmpty(v) {
+		return "null"
+	}
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return fmt.Sprint(v.Interface())
+	}
+	return string(b)
+}
+
+// isEmpty returns whether a field is its zero value or an empty map or slice
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+	"github.com/dnguy078/healthcheck/pkg/storage"
+)
+
+func TestLoad(t *testing.T) {
+	want := &File{
+		Checks: []*models.CreateHealthCheckRequest{
+			{
+				Endpoint:   "https://checkout.example.com/health",
+				Interval:   "30s",
+				Labels:     map[string]string{"team": "checkout", "env": "production"},
+				Assertions: []models.Assertion{{Type: "jsonpath", Path: "$.status", Value: "ok"}},
+			},
+			{
+				Endpoint: "tcp://redis.internal:6379",
+				Send:     "PING\r\n",
+				Expect:   "+PONG",
+			},
+		},
+	}
+	tests := []struct {
+		name     string
+		filePath string
+		wantErr  bool
+	}{
+		{name: "yaml", filePath: "./testdata/checks.yaml"},
+		{name: "json", filePath: "./testdata/checks.json"},
+		{name: "unknown field", filePath: "./testdata/unknown_field.yaml", wantErr: true},
+		{name: "missing file", filePath: "./testdata/missing.yaml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(tt.filePath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, want) {
+				t.Errorf("Load() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoad_Scalars(t *testing.T) {
+	got, err := Load("./testdata/scalars.yaml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := &models.CreateHealthCheckRequest{
+		Endpoint:       "https://canary.example.com/health",
+		ExpectedStatus: "200",
+		Labels:         map[string]string{"tier": "1", "canary": "yes"},
+		Assertions:     []models.Assertion{{Type: "contains", Value: "true"}},
+		MaxBodyBytes:   1024,
+	}
+	if len(got.Checks) != 1 || !reflect.DeepEqual(got.Checks[0], want) {
+		t.Errorf("Load() = %+v, want %+v", got.Checks, want)
+	}
+}
+
+// TestRequestTags checks the yaml names of the config file match the api's json names
+func TestRequestTags(t *testing.T) {
+	for _, typ := range []reflect.Type{reflect.TypeOf(models.CreateHealthCheckRequest{}), reflect.TypeOf(models.Assertion{})} {
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			if f.Tag.Get("json") != f.Tag.Get("yaml") {
+				t.Errorf("%s.%s: json tag %q does not match yaml tag %q", typ.Name(), f.Name, f.Tag.Get("json"), f.Tag.Get("yaml"))
+			}
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	db := storage.NewCollection("")
+	api := &models.HealthCheck{ID: "api", Endpoint: "https://api.example.com", Tracking: models.Tracking{State: models.StateUnknown}}
+	db.Create(api)
+
+	// initial load creates every check
+	f := &File{Checks: []*models.CreateHealthCheckRequest{
+		{Endpoint: "https://a.example.com", Interval: "30s"},
+		{Endpoint: "https://b.example.com"},
+		{Endpoint: "https://c.example.com", Labels: map[string]string{"team": "web"}},
+	}}
+	changes, err := Reconcile(db, f)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if got := actions(changes); !reflect.DeepEqual(got, []string{"created https://a.example.com", "created https://b.example.com", "created https://c.example.com"}) {
+		t.Errorf("unexpected changes %v", got)
+	}
+	ids := map[string]string{}
+	for _, hc := range db.List() {
+		ids[hc.Endpoint] = hc.ID
+		if hc.Endpoint != api.Endpoint && hc.Source != models.SourceConfig {
+			t.Errorf("expected %s to be marked as managed by the config", hc.Endpoint)
+		}
+	}
+
+	// reconciling the same file changes nothing
+	if changes, err := Reconcile(db, f); err != nil || len(changes) != 0 {
+		t.Errorf("expected no changes, got %v, %v", changes, err)
+	}
+
+	// a is updated, b is removed, c is unchanged and d is added
+	f = &File{Checks: []*models.CreateHealthCheckRequest{
+		{Endpoint: "https://a.example.com", Interval: "10s", Method: "head"},
+		{Endpoint: "https://c.example.com", Labels: map[string]string{"team": "web"}},
+		{Endpoint: "https://d.example.com"},
+	}}
+	changes, err = Reconcile(db, f)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if got := actions(changes); !reflect.DeepEqual(got, []string{"deleted https://b.example.com", "updated https://a.example.com", "created https://d.example.com"}) {
+		t.Errorf("unexpected changes %v", got)
+	}
+	wantFields := []FieldChange{{Name: "interval", Old: `"30s"`, New: `"10s"`}, {Name: "method", Old: "null", New: `"HEAD"`}}
+	if !reflect.DeepEqual(changes[1].Fields, wantFields) {
+		t.Errorf("unexpected updated fields %v", changes[1].Fields)
+	}
+	if got, want := changes[1].String(), `updated https://a.example.com (`+ids["https://a.example.com"]+`): interval "30s" -> "10s", method null -> "HEAD"`; got != want {
+		t.Errorf("got %s, expected %s", got, want)
+	}
+	a, err := db.Get(ids["https://a.example.com"])
+	if err != nil || a.Interval != "10s" || a.Method != "HEAD" {
+		t.Errorf("expected a to be updated in place, got %+v, %v", a, err)
+	}
+	if _, err := db.Get("api"); err != nil {
+		t.Error("expected the api created check to be kept")
+	}
+	if len(db.List()) != 4 {
+		t.Errorf("expected 4 checks, got %d", len(db.List()))
+	}
+}
+
+func TestReconcile_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		check *models.CreateHealthCheckRequest
+	}{
+		{name: "empty endpoint", check: &models.CreateHealthCheckRequest{}},
+		{name: "unsupported scheme", check: &models.CreateHealthCheckRequest{Endpoint: "ftp://example.com"}},
+		{name: "invalid option", check: &models.CreateHealthCheckRequest{Endpoint: "https://b.example.com", ExpectedStatus: "2xx"}},
+		{name: "unknown parent", check: &models.CreateHealthCheckRequest{Endpoint: "https://b.example.com", Parents: []string{"missing"}}},
+		{name: "parent deleted by the file", check: &models.CreateHealthCheckRequest{Endpoint: "https://b.example.com", Parents: []string{"old"}}},
+		{name: "member deleted by the file", check: &models.CreateHealthCheckRequest{Endpoint: "composite://b", Members: []string{"https://old.example.com"}}},
+		{name: "api endpoint", check: &models.CreateHealthCheckRequest{Endpoint: "https://api.example.com"}},
+		{name: "repeated endpoint", check: &models.CreateHealthCheckRequest{Endpoint: "https://a.example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := storage.NewCollection("")
+			db.Create(&models.HealthCheck{ID: "api", Endpoint: "https://api.example.com"})
+			db.Create(&models.HealthCheck{ID: "old", Endpoint: "https://old.example.com", Source: models.SourceConfig})
+
+			f := &File{Checks: []*models.CreateHealthCheckRequest{{Endpoint: "https://a.example.com"}, tt.check}}
+			if _, err := Reconcile(db, f); err == nil {
+				t.Fatal("expected an error")
+			}
+			// nothing is changed
+			if len(db.List()) != 2 {
+				t.Errorf("expected the checks to be unchanged, got %d checks", len(db.List()))
+			}
+		})
+	}
+}
+
+func TestReconcile_References(t *testing.T) {
+	db := storage.NewCollection("")
+	db.Create(&models.HealthCheck{ID: "api", Endpoint: "https://api.example.com"})
+
+	// checks refer to each other and to api created checks by endpoint, in any order
+	f := &File{Checks: []*models.CreateHealthCheckRequest{
+		{Endpoint: "https://app.example.com", Parents: []string{"https://lb.example.com"}},
+		{Endpoint: "https://lb.example.com", Parents: []string{"api"}},
+		{Endpoint: "composite://shop", Members: []string{"https://app.example.com", "https://api.example.com"}},
+	}}
+	if _, err := Reconcile(db, f); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	ids := map[string]string{}
+	for _, hc := range db.List() {
+		ids[hc.Endpoint] = hc.ID
+	}
+	app, _ := db.Get(ids["https://app.example.com"])
+	if !reflect.DeepEqual(app.Parents, []string{ids["https://lb.example.com"]}) {
+		t.Errorf("expected app's parent to be resolved to lb's id, got %v", app.Parents)
+	}
+	shop, _ := db.Get(ids["composite://shop"])
+	if !reflect.DeepEqual(shop.Members, []string{ids["https://app.example.com"], "api"}) {
+		t.Errorf("expected shop's members to be resolved to ids, got %v", shop.Members)
+	}
+	if f.Checks[0].Parents[0] != "https://lb.example.com" {
+		t.Error("expected the file to be left unchanged")
+	}
+
+	// the same file resolves to the same ids so nothing changes
+	if changes, err := Reconcile(db, f); err != nil || len(changes) != 0 {
+		t.Errorf("expected no changes, got %v, %v", changes, err)
+	}
+
+	// a cycle between checks of the file is rejected
+	f.Checks[1].Parents = []string{"https://app.example.com"}
+	if _, err := Reconcile(db, f); err == nil {
+		t.Error("expected the dependency cycle to be rejected")
+	}
+}
+
+// actions returns the action and endpoint of each change
+func actions(changes []Change) []string {
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.Action+" "+c.Endpoint)
+	}
+	return got
+}
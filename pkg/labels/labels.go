@@ -0,0 +1,136 @@
+package labels
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// operators a requirement can use
+const (
+	Equals       = "="
+	NotEquals    = "!="
+	Exists       = "exists"
+	DoesNotExist = "!exists"
+)
+
+// Requirement is a single condition on a label
+type Requirement struct {
+	Key      string
+	Operator string
+	Value    string
+}
+
+// Selector is a set of requirements that must all match, an empty selector matches everything
+type Selector []Requirement
+
+// Parse parses a comma separated selector, each requirement is one of key=value, key==value, key!=value,
+// key (the label is set) or !key (the label is not set). ie) team=payments,env!=staging
+func Parse(selector string) (Selector, error) {
+	var s Selector
+	for _, part := range strings.Split(selector, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var r Requirement
+		switch {
+		case strings.Contains(part, "!="):
+			kv := strings.SplitN(part, "!=", 2)
+			r = Requirement{Key: kv[0], Operator: NotEquals, Value: kv[1]}
+		case strings.Contains(part, "=="):
+			kv := strings.SplitN(part, "==", 2)
+			r = Requirement{Key: kv[0], Operator: Equals, Value: kv[1]}
+		case strings.Contains(part, "="):
+			kv := strings.SplitN(part, "=", 2)
+			r = Requirement{Key: kv[0], Operator: Equals, Value: kv[1]}
+		case strings.HasPrefix(part, "!"):
+			r = Requirement{Key: part[1:], Operator: DoesNotExist}
+		default:
+			r = Requirement{Key: part, Operator: Exists}
+		}
+
+		r.Key, r.Value = strings.TrimSpace(r.Key), strings.TrimSpace(r.Value)
+		if err := validateKey(r.Key); err != nil {
+			return nil, fmt.Errorf("invalid label selector %q: %s", part, err)
+		}
+		if strings.ContainsAny(r.Value, "=!") {
+			return nil, fmt.Errorf("invalid label selector %q", part)
+		}
+		s = append(s, r)
+	}
+	return s, nil
+}
+
+// Matches reports whether the labels satisfy every requirement
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		v, ok := labels[r.Key]
+		switch r.Operator {
+		case Equals:
+			if !ok || v != r.Value {
+				return false
+			}
+		case NotEquals:
+			// like kubernetes, a missing label is not equal to the value
+			if ok && v == r.Value {
+				return false
+			}
+		case Exists:
+			if !ok {
+				return false
+			}
+		case DoesNotExist:
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// String returns the selector in the format accepted by Parse
+func (s Selector) String() string {
+	parts := make([]string, 0, len(s))
+	for _, r := range s {
+		switch r.Operator {
+		case Exists:
+			parts = append(parts, r.Key)
+		case DoesNotExist:
+			parts = append(parts, "!"+r.Key)
+		default:
+			parts = append(parts, r.Key+r.Operator+r.Value)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Validate checks label keys and values can be used in a selector
+func Validate(labels map[string]string) error {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if err := validateKey(k); err != nil {
+			return fmt.Errorf("invalid label %q: %s", k, err)
+		}
+		if strings.ContainsAny(labels[k], ",=!") {
+			return fmt.Errorf("invalid label %q: value cannot contain , = or !", k)
+		}
+	}
+	return nil
+}
+
+func validateKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("empty key")
+	}
+	if strings.ContainsAny(key, ",=! ") {
+		return fmt.Errorf("key cannot contain , = ! or spaces")
+	}
+	return nil
+}
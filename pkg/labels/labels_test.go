@@ -0,0 +1,86 @@
+package labels
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     string
+		wantErr  bool
+	}{
+		{selector: "", want: ""},
+		{selector: "team=payments", want: "team=payments"},
+		{selector: "team==payments, env!=staging", want: "team=payments,env!=staging"},
+		{selector: "canary,!deprecated", want: "canary,!deprecated"},
+		{selector: "team=", want: "team="},
+		{selector: "=payments", wantErr: true},
+		{selector: "team=pay=ments", wantErr: true},
+		{selector: "!", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			got, err := Parse(tt.selector)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("Parse() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelector_Matches(t *testing.T) {
+	labels := map[string]string{"team": "payments", "env": "production", "canary": ""}
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{selector: "", want: true},
+		{selector: "team=payments", want: true},
+		{selector: "team=search"},
+		{selector: "team=payments,env!=staging", want: true},
+		{selector: "team=payments,env!=production"},
+		{selector: "region!=eu", want: true},
+		{selector: "canary", want: true},
+		{selector: "canary=", want: true},
+		{selector: "region"},
+		{selector: "!region", want: true},
+		{selector: "!team"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			s, err := Parse(tt.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Matches(labels); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		wantErr bool
+	}{
+		{name: "none"},
+		{name: "valid", labels: map[string]string{"team": "payments", "env": ""}},
+		{name: "empty key", labels: map[string]string{"": "payments"}, wantErr: true},
+		{name: "key with operator", labels: map[string]string{"team!": "payments"}, wantErr: true},
+		{name: "value with comma", labels: map[string]string{"team": "a,b"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.labels); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
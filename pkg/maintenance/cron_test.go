@@ -0,0 +1,66 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{spec: "* * * * *"},
+		{spec: "*/15 2-4 1,15 * 1-5"},
+		{spec: "5/20 0 * 12 7"},
+		{spec: "* * * *", wantErr: true},
+		{spec: "60 * * * *", wantErr: true},
+		{spec: "* 24 * * *", wantErr: true},
+		{spec: "* * 0 * *", wantErr: true},
+		{spec: "* * * 13 *", wantErr: true},
+		{spec: "*/0 * * * *", wantErr: true},
+		{spec: "5-1 * * * *", wantErr: true},
+		{spec: "mon * * * *", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			if _, err := ParseCron(tt.spec); (err != nil) != tt.wantErr {
+				t.Errorf("ParseCron() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCron_Next(t *testing.T) {
+	// a Wednesday
+	from := time.Date(2019, 11, 27, 10, 30, 15, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{spec: "* * * * *", want: time.Date(2019, 11, 27, 10, 31, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", want: time.Date(2019, 11, 27, 10, 45, 0, 0, time.UTC)},
+		{spec: "0 2 * * *", want: time.Date(2019, 11, 28, 2, 0, 0, 0, time.UTC)},
+		{spec: "0 2 * * 0", want: time.Date(2019, 12, 1, 2, 0, 0, 0, time.UTC)},
+		{spec: "0 2 * * 7", want: time.Date(2019, 12, 1, 2, 0, 0, 0, time.UTC)},
+		{spec: "30 9 1 * *", want: time.Date(2019, 12, 1, 9, 30, 0, 0, time.UTC)},
+		{spec: "0 0 1 1 *", want: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// both day fields restricted matches either
+		{spec: "0 0 15 * 5", want: time.Date(2019, 11, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 29 2 *", want: time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 31 2 *", want: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			c, err := ParseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseCron() error = %v", err)
+			}
+			if got := c.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package maintenance
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/labels"
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+// Validate checks a maintenance window is complete, an empty mode defaults to skip
+func Validate(mw *models.MaintenanceWindow) error {
+	if mw.Mode == "" {
+		mw.Mode = models.MaintenanceSkip
+	}
+	if mw.Mode != models.MaintenanceSkip && mw.Mode != models.MaintenanceSuppress {
+		return fmt.Errorf("invalid mode %q, expected %s or %s", mw.Mode, models.MaintenanceSkip, models.MaintenanceSuppress)
+	}
+	if len(mw.CheckIDs) == 0 && mw.Selector == "" {
+		return fmt.Errorf("maintenance window requires check_ids or a selector")
+	}
+	if _, err := labels.Parse(mw.Selector); err != nil {
+		return err
+	}
+
+	oneOff := mw.Start != 0 || mw.End != 0
+	recurring := mw.Cron != "" || mw.Duration != ""
+	switch {
+	case oneOff && recurring:
+		return fmt.Errorf("maintenance window is either one-off with start and end or recurring with cron and duration")
+	case oneOff:
+		if mw.End <= mw.Start {
+			return fmt.Errorf("maintenance window end must be after start")
+		}
+	case recurring:
+		if _, err := ParseCron(mw.Cron); err != nil {
+			return err
+		}
+		d, err := time.ParseDuration(mw.Duration)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid duration %q", mw.Duration)
+		}
+	default:
+		return fmt.Errorf("maintenance window requires start and end, or cron and duration")
+	}
+	return nil
+}
+
+// Active returns whether a window is in effect at now, invalid windows are never active
+func Active(mw *models.MaintenanceWindow, now time.Time) bool {
+	if mw.Cron == "" {
+		return mw.Start <= now.Unix() && now.Unix() < mw.End
+	}
+
+	c, err := ParseCron(mw.Cron)
+	if err != nil {
+		return false
+	}
+	d, err := time.ParseDuration(mw.Duration)
+	if err != nil {
+		return false
+	}
+	// active if the window last started within its duration
+	start := c.Next(now.UTC().Add(-d))
+	return !start.IsZero() && !start.After(now)
+}
+
+// Applies returns whether a window covers a healthcheck, by id or by its labels matching the selector
+func Applies(mw *models.MaintenanceWindow, hc *models.HealthCheck) bool {
+	for _, id := range mw.CheckIDs {
+		if id == hc.ID {
+			return true
+		}
+	}
+	if mw.Selector == "" {
+		return false
+	}
+	selector, err := labels.Parse(mw.Selector)
+	return err == nil && selector.Matches(hc.Labels)
+}
+
+// Mode returns the mode of the active windows covering a healthcheck, skip takes precedence over suppress.
+// Returns an empty string if the healthcheck is not in maintenance
+func Mode(windows []*models.MaintenanceWindow, hc *models.HealthCheck, now time.Time) string {
+	mode := ""
+	for _, mw := range windows {
+		if !Applies(mw, hc) || !Active(mw, now) {
+			continue
+		}
+		if mw.Mode == models.MaintenanceSkip {
+			return models.MaintenanceSkip
+		}
+		mode = mw.Mode
+	}
+	return mode
+}
@@ -0,0 +1,105 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		window   models.MaintenanceWindow
+		wantMode string
+		wantErr  bool
+	}{
+		{name: "one-off", window: models.MaintenanceWindow{CheckIDs: []string{"a"}, Start: 100, End: 200}, wantMode: models.MaintenanceSkip},
+		{name: "recurring", window: models.MaintenanceWindow{Selector: "role=db", Mode: models.MaintenanceSuppress, Cron: "0 2 * * 0", Duration: "2h"}, wantMode: models.MaintenanceSuppress},
+		{name: "no checks", window: models.MaintenanceWindow{Start: 100, End: 200}, wantErr: true},
+		{name: "invalid selector", window: models.MaintenanceWindow{Selector: "role=db=primary", Start: 100, End: 200}, wantErr: true},
+		{name: "invalid mode", window: models.MaintenanceWindow{CheckIDs: []string{"a"}, Mode: "mute", Start: 100, End: 200}, wantErr: true},
+		{name: "end before start", window: models.MaintenanceWindow{CheckIDs: []string{"a"}, Start: 200, End: 100}, wantErr: true},
+		{name: "both kinds", window: models.MaintenanceWindow{CheckIDs: []string{"a"}, Start: 100, End: 200, Cron: "* * * * *", Duration: "1h"}, wantErr: true},
+		{name: "no schedule", window: models.MaintenanceWindow{CheckIDs: []string{"a"}}, wantErr: true},
+		{name: "invalid cron", window: models.MaintenanceWindow{CheckIDs: []string{"a"}, Cron: "0 2 * *", Duration: "2h"}, wantErr: true},
+		{name: "missing duration", window: models.MaintenanceWindow{CheckIDs: []string{"a"}, Cron: "0 2 * * *"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&tt.window)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && tt.window.Mode != tt.wantMode {
+				t.Errorf("Mode = %s, want %s", tt.window.Mode, tt.wantMode)
+			}
+		})
+	}
+}
+
+func TestActive(t *testing.T) {
+	// a Sunday
+	now := time.Date(2019, 12, 1, 3, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		window models.MaintenanceWindow
+		want   bool
+	}{
+		{name: "one-off in progress", window: models.MaintenanceWindow{Start: now.Add(-time.Hour).Unix(), End: now.Add(time.Hour).Unix()}, want: true},
+		{name: "one-off ended", window: models.MaintenanceWindow{Start: now.Add(-2 * time.Hour).Unix(), End: now.Unix()}},
+		{name: "one-off not started", window: models.MaintenanceWindow{Start: now.Add(time.Minute).Unix(), End: now.Add(time.Hour).Unix()}},
+		{name: "recurring in progress", window: models.MaintenanceWindow{Cron: "0 2 * * 0", Duration: "2h"}, want: true},
+		{name: "recurring starting now", window: models.MaintenanceWindow{Cron: "0 3 * * *", Duration: "1m"}, want: true},
+		{name: "recurring ended", window: models.MaintenanceWindow{Cron: "0 2 * * 0", Duration: "1h"}},
+		{name: "recurring other day", window: models.MaintenanceWindow{Cron: "0 2 * * 1", Duration: "2h"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Active(&tt.window, now); got != tt.want {
+				t.Errorf("Active() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMode(t *testing.T) {
+	now := time.Now()
+	active := func(mode string, ids []string, selector string) *models.MaintenanceWindow {
+		return &models.MaintenanceWindow{Mode: mode, CheckIDs: ids, Selector: selector, Start: now.Add(-time.Hour).Unix(), End: now.Add(time.Hour).Unix()}
+	}
+	hc := &models.HealthCheck{ID: "a", Labels: map[string]string{"role": "db", "region": "eu"}}
+
+	tests := []struct {
+		name    string
+		windows []*models.MaintenanceWindow
+		want    string
+	}{
+		{name: "none"},
+		{name: "by id", windows: []*models.MaintenanceWindow{active(models.MaintenanceSuppress, []string{"a"}, "")}, want: models.MaintenanceSuppress},
+		{name: "by selector", windows: []*models.MaintenanceWindow{active(models.MaintenanceSkip, nil, "region=eu,role!=web")}, want: models.MaintenanceSkip},
+		{name: "other checks", windows: []*models.MaintenanceWindow{active(models.MaintenanceSkip, []string{"b"}, "role=web")}},
+		{
+			name: "skip takes precedence",
+			windows: []*models.MaintenanceWindow{
+				active(models.MaintenanceSuppress, []string{"a"}, ""),
+				active(models.MaintenanceSkip, nil, "role=db"),
+			},
+			want: models.MaintenanceSkip,
+		},
+		{
+			name:    "inactive",
+			windows: []*models.MaintenanceWindow{{Mode: models.MaintenanceSkip, CheckIDs: []string{"a"}, Start: now.Add(time.Hour).Unix(), End: now.Add(2 * time.Hour).Unix()}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Mode(tt.windows, hc, now); got != tt.want {
+				t.Errorf("Mode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
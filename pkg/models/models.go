@@ -1,17 +1,144 @@
 package models
 
 import (
+	"strings"
+
 	"github.com/dnguy078/healthcheck/pkg/utils"
 )
 
+// healthcheck states
+const (
+	StateUnknown  = "UNKNOWN"
+	StateUp       = "UP"
+	StateDown     = "DOWN"
+	StateDegraded = "DEGRADED"
+	StateFlapping = "FLAPPING"
+	// StateUnreachable is a failing check with a parent that is DOWN or UNREACHABLE
+	StateUnreachable = "UNREACHABLE"
+)
+
+// maintenance window modes
+const (
+	// MaintenanceSkip stops healthchecks from running during the window
+	MaintenanceSkip = "skip"
+	// MaintenanceSuppress runs healthchecks and records their results, but their state is left unchanged so
+	// no notifications are sent
+	MaintenanceSuppress = "suppress"
+)
+
+// batch item results
+const (
+	BatchCreated   = "created"
+	BatchDuplicate = "duplicate"
+	BatchInvalid   = "invalid"
+	BatchDeleted   = "deleted"
+	BatchNotFound  = "not_found"
+)
+
+// RedactedValue replaces header values and request bodies in api responses
+const RedactedValue = "REDACTED"
+
+// SourceConfig marks healthchecks managed by the --config file, they are created, updated and deleted to
+// match it. Healthchecks created through the api have no source
+const SourceConfig = "config"
+
 type HealthCheck struct {
-	ID       string `json:"id"`
+	ID string `json:"id"`
+	Result
+	Tracking
+	// Source is what manages the healthcheck, empty for the api
+	Source   string            `json:"source,omitempty"`
+	Endpoint string            `json:"endpoint"`
+	Method   string            `json:"method,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     string            `json:"body,omitempty"`
+	// Labels are free-form key/values healthchecks are filtered and selected by, ie) team=payments
+	Labels map[string]string `json:"labels,omitempty"`
+	// Parents are the ids of checks this check depends on, ie) the load balancer in front of it
+	Parents []string `json:"parents,omitempty"`
+	// ExpectedStatus lists the accepted status codes and ranges, ie) 200-299,301
+	ExpectedStatus string      `json:"expected_status,omitempty"`
+	Assertions     []Assertion `json:"assertions,omitempty"`
+	// MaxBodyBytes caps how much of the response body is read for assertions
+	MaxBodyBytes int64 `json:"max_body_bytes,omitempty"`
+	// Send is written to tcp endpoints after connecting, Expect is the prefix the response must start with
+	Send   string `json:"send,omitempty"`
+	Expect string `json:"expect,omitempty"`
+	// RecordType and ExpectAnswers are used by dns checks, the record type defaults to A
+	RecordType    string   `json:"record_type,omitempty"`
+	ExpectAnswers []string `json:"expect_answers,omitempty"`
+	// GRPCService is the service name sent to grpc health checks, empty checks the whole server
+	GRPCService string `json:"grpc_service,omitempty"`
+	GRPCTLS     bool   `json:"grpc_tls,omitempty"`
+	// Members and MemberSelector pick the checks a composite:// check is derived from, by id and by label
+	// selector. MinUp is how many of them must be UP, defaults to all of them
+	Members        []string `json:"members,omitempty"`
+	MemberSelector string   `json:"member_selector,omitempty"`
+	MinUp          int      `json:"min_up,omitempty"`
+	// MaxLatency fails a check that takes longer than the duration, ie) 500ms
+	MaxLatency string `json:"max_latency,omitempty"`
+	// Interval overrides how often the check is scheduled, Timeout overrides the default timeout, ie) 5s
+	Interval string `json:"interval,omitempty"`
+	Timeout  string `json:"timeout,omitempty"`
+	// CertWarnDays and CertFailDays warn or fail a https check once its certificate chain is within that
+	// many days of expiry
+	CertWarnDays int `json:"cert_warn_days,omitempty"`
+	CertFailDays int `json:"cert_fail_days,omitempty"`
+	// FailureThreshold and SuccessThreshold override how many consecutive failures mark a check DOWN and
+	// how many consecutive successes mark it UP again
+	FailureThreshold int `json:"failure_threshold,omitempty"`
+	SuccessThreshold int `json:"success_threshold,omitempty"`
+	// Stats is only set when stats are requested inline when listing healthchecks
+	Stats []WindowStats `json:"stats,omitempty"`
+}
+
+// Result is the outcome of the most recent run of a healthcheck
+type Result struct {
 	Status   string `json:"status"`
 	Code     int32  `json:"code"`
-	Endpoint string `json:"endpoint"`
+	Healthy  bool   `json:"healthy"`
 	Checked  int64  `json:"checked"`
 	Duration string `json:"duration"`
-	Error    string `json:"error,omitempty"`
+	// DurationMs is Duration in milliseconds
+	DurationMs float64 `json:"duration_ms"`
+	// ConnectDuration is the time taken to establish a tcp connection
+	ConnectDuration string   `json:"connect_duration,omitempty"`
+	TLS             *TLSInfo `json:"tls,omitempty"`
+	Answers         []string `json:"answers,omitempty"`
+	Warning         string   `json:"warning,omitempty"`
+	Error           string   `json:"error,omitempty"`
+	// Maintenance is set on results recorded during a suppressing maintenance window
+	Maintenance bool `json:"maintenance,omitempty"`
+}
+
+// Tracking is the state of a healthcheck derived from its consecutive results. A passing result with a
+// warning is DEGRADED rather than UP, a check oscillating between passing and failing is FLAPPING
+type Tracking struct {
+	State                string  `json:"state"`
+	LastTransition       int64   `json:"last_transition,omitempty"`
+	ConsecutiveFailures  int     `json:"consecutive_failures"`
+	ConsecutiveSuccesses int     `json:"consecutive_successes"`
+	FlapScore            float64 `json:"flap_score"`
+	Flapping             bool    `json:"flapping"`
+	// Recent holds whether each of the latest results passed, oldest first, for flap detection
+	Recent []bool `json:"recent,omitempty"`
+}
+
+// Copy returns a deep copy of the tracking state
+func (t Tracking) Copy() Tracking {
+	if t.Recent != nil {
+		t.Recent = append([]bool(nil), t.Recent...)
+	}
+	return t
+}
+
+// TLSInfo describes the certificate chain presented by a https endpoint. Expiry is the earliest expiry
+// in the chain, issuer and SANs are from the leaf certificate
+type TLSInfo struct {
+	Expiry   int64    `json:"expiry"`
+	DaysLeft int      `json:"days_left"`
+	Issuer   string   `json:"issuer"`
+	SANs     []string `json:"sans,omitempty"`
 }
 
 func NewHealthCheck(endpoint string) (*HealthCheck, error) {
@@ -23,9 +150,71 @@ func NewHealthCheck(endpoint string) (*HealthCheck, error) {
 	return &HealthCheck{
 		Endpoint: endpoint,
 		ID:       uuid,
+		Tracking: Tracking{State: StateUnknown},
 	}, nil
 }
 
+// Copy returns a deep copy of the healthcheck
+func (hc *HealthCheck) Copy() *HealthCheck {
+	cp := *hc
+	if hc.Headers != nil {
+		cp.Headers = make(map[string]string, len(hc.Headers))
+		for k, v := range hc.Headers {
+			cp.Headers[k] = v
+		}
+	}
+	if hc.Labels != nil {
+		cp.Labels = make(map[string]string, len(hc.Labels))
+		for k, v := range hc.Labels {
+			cp.Labels[k] = v
+		}
+	}
+	if hc.Parents != nil {
+		cp.Parents = append([]string(nil), hc.Parents...)
+	}
+	if hc.Assertions != nil {
+		cp.Assertions = append([]Assertion(nil), hc.Assertions...)
+	}
+	if hc.ExpectAnswers != nil {
+		cp.ExpectAnswers = append([]string(nil), hc.ExpectAnswers...)
+	}
+	if hc.Members != nil {
+		cp.Members = append([]string(nil), hc.Members...)
+	}
+	cp.Result = hc.Result.Copy()
+	cp.Tracking = hc.Tracking.Copy()
+	if hc.Stats != nil {
+		cp.Stats = append([]WindowStats(nil), hc.Stats...)
+	}
+	return &cp
+}
+
+// Redacted returns a copy of the healthcheck with its header values and request body replaced by
+// RedactedValue, they can hold credentials and are left out of api responses
+func (hc *HealthCheck) Redacted() *HealthCheck {
+	cp := hc.Copy()
+	for k := range cp.Headers {
+		cp.Headers[k] = RedactedValue
+	}
+	if cp.Body != "" {
+		cp.Body = RedactedValue
+	}
+	return cp
+}
+
+// Copy returns a deep copy of the result
+func (r Result) Copy() Result {
+	if r.Answers != nil {
+		r.Answers = append([]string(nil), r.Answers...)
+	}
+	if r.TLS != nil {
+		tls := *r.TLS
+		tls.SANs = append([]string(nil), r.TLS.SANs...)
+		r.TLS = &tls
+	}
+	return r
+}
+
 type HealthChecks []*HealthCheck
 
 func (hcs HealthChecks) Len() int {
@@ -43,6 +232,35 @@ type HealthCheckList struct {
 	Page  int          `json:"page"`
 	Total int          `json:"total"`
 	Size  int          `json:"size"`
+	// NextCursor fetches the next page of a cursor scan, empty on its last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// HistoryList is a page of a healthcheck's past results, newest first
+type HistoryList struct {
+	Items []Result `json:"items"`
+	Page  int      `json:"page"`
+	Total int      `json:"total"`
+	Size  int      `json:"size"`
+}
+
+// WindowStats summarizes the results of a healthcheck over a rolling window. Covered is the span from the
+// window's oldest result to now, shorter than the window when less history is kept. Uptime is a
+// percentage, latency percentiles are in milliseconds. They are omitted if the window has no results
+type WindowStats struct {
+	Window  string   `json:"window"`
+	Covered string   `json:"covered,omitempty"`
+	Checks  int      `json:"checks"`
+	Uptime  *float64 `json:"uptime,omitempty"`
+	P50     *float64 `json:"p50_ms,omitempty"`
+	P90     *float64 `json:"p90_ms,omitempty"`
+	P99     *float64 `json:"p99_ms,omitempty"`
+}
+
+// HealthCheckStats is the stats of a single healthcheck
+type HealthCheckStats struct {
+	ID    string        `json:"id"`
+	Stats []WindowStats `json:"stats"`
 }
 
 type CreateHealthCheckResponse struct {
@@ -50,6 +268,157 @@ type CreateHealthCheckResponse struct {
 	Endpoint string `json:"endpoint"`
 }
 
+// BatchResponse is the result of each item of a batch create or delete, in request order. Nothing is
+// changed on a dry run, the results are what would have happened
+type BatchResponse struct {
+	DryRun  bool          `json:"dry_run"`
+	Results []BatchResult `json:"results"`
+}
+
+// BatchResult is the result of a single item of a batch, Index is its position in the request
+type BatchResult struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"`
+	Result   string `json:"result"`
+	Error    string `json:"error,omitempty"`
+}
+
+// CreateHealthCheckRequest is the configuration of a healthcheck as sent to the api and listed in the
+// config file, the yaml names match the json ones
 type CreateHealthCheckRequest struct {
-	Endpoint string `json:"endpoint"`
+	Endpoint string            `json:"endpoint" yaml:"endpoint"`
+	Method   string            `json:"method,omitempty" yaml:"method,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body     string            `json:"body,omitempty" yaml:"body,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Parents  []string          `json:"parents,omitempty" yaml:"parents,omitempty"`
+	// ExpectedStatus lists the accepted status codes and ranges, ie) 200-299,301
+	ExpectedStatus   string      `json:"expected_status,omitempty" yaml:"expected_status,omitempty"`
+	Assertions       []Assertion `json:"assertions,omitempty" yaml:"assertions,omitempty"`
+	MaxBodyBytes     int64       `json:"max_body_bytes,omitempty" yaml:"max_body_bytes,omitempty"`
+	CertWarnDays     int         `json:"cert_warn_days,omitempty" yaml:"cert_warn_days,omitempty"`
+	CertFailDays     int         `json:"cert_fail_days,omitempty" yaml:"cert_fail_days,omitempty"`
+	Send             string      `json:"send,omitempty" yaml:"send,omitempty"`
+	Expect           string      `json:"expect,omitempty" yaml:"expect,omitempty"`
+	RecordType       string      `json:"record_type,omitempty" yaml:"record_type,omitempty"`
+	ExpectAnswers    []string    `json:"expect_answers,omitempty" yaml:"expect_answers,omitempty"`
+	GRPCService      string      `json:"grpc_service,omitempty" yaml:"grpc_service,omitempty"`
+	GRPCTLS          bool        `json:"grpc_tls,omitempty" yaml:"grpc_tls,omitempty"`
+	Members          []string    `json:"members,omitempty" yaml:"members,omitempty"`
+	MemberSelector   string      `json:"member_selector,omitempty" yaml:"member_selector,omitempty"`
+	MinUp            int         `json:"min_up,omitempty" yaml:"min_up,omitempty"`
+	MaxLatency       string      `json:"max_latency,omitempty" yaml:"max_latency,omitempty"`
+	Interval         string      `json:"interval,omitempty" yaml:"interval,omitempty"`
+	Timeout          string      `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	FailureThreshold int         `json:"failure_threshold,omitempty" yaml:"failure_threshold,omitempty"`
+	SuccessThreshold int         `json:"success_threshold,omitempty" yaml:"success_threshold,omitempty"`
+}
+
+// Apply sets a healthcheck's configuration from a create or update request
+func (req *CreateHealthCheckRequest) Apply(hc *HealthCheck) {
+	hc.Endpoint = req.Endpoint
+	hc.Method = strings.ToUpper(req.Method)
+	hc.Headers = req.Headers
+	hc.Body = req.Body
+	hc.Labels = req.Labels
+	hc.Parents = req.Parents
+	hc.ExpectedStatus = req.ExpectedStatus
+	hc.Assertions = req.Assertions
+	hc.MaxBodyBytes = req.MaxBodyBytes
+	hc.CertWarnDays = req.CertWarnDays
+	hc.CertFailDays = req.CertFailDays
+	hc.Send = req.Send
+	hc.Expect = req.Expect
+	hc.RecordType = strings.ToUpper(req.RecordType)
+	hc.ExpectAnswers = req.ExpectAnswers
+	hc.GRPCService = req.GRPCService
+	hc.GRPCTLS = req.GRPCTLS
+	hc.Members = req.Members
+	hc.MemberSelector = req.MemberSelector
+	hc.MinUp = req.MinUp
+	hc.MaxLatency = req.MaxLatency
+	hc.Interval = req.Interval
+	hc.Timeout = req.Timeout
+	hc.FailureThreshold = req.FailureThreshold
+	hc.SuccessThreshold = req.SuccessThreshold
+}
+
+// Unredact restores the header values and body of the existing healthcheck that were sent back as
+// RedactedValue, ie) when a healthcheck read from the api is PUT back with changes
+func (req *CreateHealthCheckRequest) Unredact(existing *HealthCheck) {
+	for k, v := range req.Headers {
+		if old, ok := existing.Headers[k]; ok && v == RedactedValue {
+			req.Headers[k] = old
+		}
+	}
+	if req.Body == RedactedValue {
+		req.Body = existing.Body
+	}
+}
+
+// Request returns the request that would create the healthcheck's configuration
+func (hc *HealthCheck) Request() *CreateHealthCheckRequest {
+	hc = hc.Copy()
+	return &CreateHealthCheckRequest{
+		Endpoint:         hc.Endpoint,
+		Method:           hc.Method,
+		Headers:          hc.Headers,
+		Body:             hc.Body,
+		Labels:           hc.Labels,
+		Parents:          hc.Parents,
+		ExpectedStatus:   hc.ExpectedStatus,
+		Assertions:       hc.Assertions,
+		MaxBodyBytes:     hc.MaxBodyBytes,
+		CertWarnDays:     hc.CertWarnDays,
+		CertFailDays:     hc.CertFailDays,
+		Send:             hc.Send,
+		Expect:           hc.Expect,
+		RecordType:       hc.RecordType,
+		ExpectAnswers:    hc.ExpectAnswers,
+		GRPCService:      hc.GRPCService,
+		GRPCTLS:          hc.GRPCTLS,
+		Members:          hc.Members,
+		MemberSelector:   hc.MemberSelector,
+		MinUp:            hc.MinUp,
+		MaxLatency:       hc.MaxLatency,
+		Interval:         hc.Interval,
+		Timeout:          hc.Timeout,
+		FailureThreshold: hc.FailureThreshold,
+		SuccessThreshold: hc.SuccessThreshold,
+	}
+}
+
+// MaintenanceWindow silences healthchecks while it is active. It applies to the healthchecks listed in
+// CheckIDs and to those whose labels match the Selector, ie) role=db. A window is either one-off, from
+// Start until End as unix timestamps, or recurring, starting at each time matching the 5 field Cron
+// expression in UTC and lasting Duration
+type MaintenanceWindow struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name,omitempty"`
+	CheckIDs []string `json:"check_ids,omitempty"`
+	Selector string   `json:"selector,omitempty"`
+	// Mode is skip or suppress, defaults to skip
+	Mode     string `json:"mode"`
+	Start    int64  `json:"start,omitempty"`
+	End      int64  `json:"end,omitempty"`
+	Cron     string `json:"cron,omitempty"`
+	Duration string `json:"duration,omitempty"`
+}
+
+// Copy returns a deep copy of the maintenance window
+func (mw *MaintenanceWindow) Copy() *MaintenanceWindow {
+	cp := *mw
+	if mw.CheckIDs != nil {
+		cp.CheckIDs = append([]string(nil), mw.CheckIDs...)
+	}
+	return &cp
+}
+
+// Assertion is a check run against the response body, type is one of contains, not_contains, regex
+// or jsonpath. Path is only used by jsonpath assertions
+type Assertion struct {
+	Type  string `json:"type" yaml:"type"`
+	Path  string `json:"path,omitempty" yaml:"path,omitempty"`
+	Value string `json:"value" yaml:"value"`
 }
@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// Config configures a notifier loaded from the notifiers file
+type Config struct {
+	Name string `json:"name"`
+	// Type is the kind of notifier, webhook or pagerduty. Defaults to webhook
+	Type           string `json:"type"`
+	URL            string `json:"url"`
+	Secret         string `json:"secret,omitempty"`
+	MaxRetries     *int   `json:"max_retries,omitempty"`
+	InitialBackoff string `json:"initial_backoff,omitempty"`
+	// Format selects a built-in payload template, one of slack, teams or json. Template is a custom
+	// text/template rendered with the event, it takes precedence over Format
+	Format      string `json:"format,omitempty"`
+	Template    string `json:"template,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	// RoutingKey is the PagerDuty integration key, URL overrides the Events API base url
+	RoutingKey string `json:"routing_key,omitempty"`
+}
+
+// LoadConfig reads a JSON file containing a list of notifier configs and builds the notifiers
+func LoadConfig(filePath string) ([]Notifier, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadFile(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []Config
+	if err := json.Unmarshal(b, &configs); err != nil {
+		return nil, err
+	}
+
+	notifiers := make([]Notifier, 0, len(configs))
+	for _, cfg := range configs {
+		n, err := New(cfg)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers, nil
+}
+
+// New builds a notifier from its config
+func New(cfg Config) (Notifier, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("notifier requires a name")
+	}
+
+	switch cfg.Type {
+	case "webhook", "":
+		return NewWebhook(cfg)
+	case "pagerduty":
+		return NewPagerDuty(cfg)
+	default:
+		return nil, fmt.Errorf("notifier %s has an unknown type %q", cfg.Name, cfg.Type)
+	}
+}
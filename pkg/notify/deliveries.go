@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"sync"
+	"time"
+)
+
+// Delivery records the outcome of sending an event to a notifier
+type Delivery struct {
+	Notifier string `json:"notifier"`
+	CheckID  string `json:"check_id"`
+	OldState string `json:"old_state"`
+	NewState string `json:"new_state"`
+	Sent     int64  `json:"sent"`
+	Duration string `json:"duration"`
+	Success  bool   `json:"success"`
+	// Ignored is set when the notifier does not send this kind of state change, nothing was delivered
+	Ignored bool   `json:"ignored,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+func newDelivery(notifier string, e Event, start time.Time, err error) Delivery {
+	d := Delivery{
+		Notifier: notifier,
+		CheckID:  e.Check.ID,
+		OldState: e.OldState,
+		NewState: e.NewState,
+		Sent:     start.Unix(),
+		Duration: time.Since(start).String(),
+		Success:  err == nil,
+	}
+	switch {
+	case err == ErrIgnored:
+		d.Ignored = true
+	case err != nil:
+		d.Error = err.Error()
+	}
+	return d
+}
+
+// DeliveryLog keeps the most recent deliveries
+type DeliveryLog struct {
+	sync.RWMutex
+	deliveries []Delivery
+	max        int
+}
+
+// NewDeliveryLog returns a log that keeps at most max deliveries
+func NewDeliveryLog(max int) *DeliveryLog {
+	return &DeliveryLog{max: max}
+}
+
+// Add records a delivery, dropping the oldest once the log is full
+func (l *DeliveryLog) Add(d Delivery) {
+	l.Lock()
+	defer l.Unlock()
+	l.deliveries = append(l.deliveries, d)
+	if len(l.deliveries) > l.max {
+		l.deliveries = l.deliveries[len(l.deliveries)-l.max:]
+	}
+}
+
+// List returns the deliveries newest first
+func (l *DeliveryLog) List() []Delivery {
+	l.RLock()
+	defer l.RUnlock()
+	list := make([]Delivery, 0, len(l.deliveries))
+	for i := len(l.deliveries) - 1; i >= 0; i-- {
+		list = append(list, l.deliveries[i])
+	}
+	return list
+}
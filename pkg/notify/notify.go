@@ -0,0 +1,190 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+// ErrIgnored is returned by notifiers for events they do not send, ie) PagerDuty ignores changes to FLAPPING
+var ErrIgnored = errors.New("event ignored by notifier")
+
+// queueSize is how many events can be waiting per notifier before new events are dropped
+var queueSize = 100
+
+// Event is sent to notifiers when a healthcheck changes state
+type Event struct {
+	Check    *models.HealthCheck `json:"check"`
+	OldState string              `json:"old_state"`
+	NewState string              `json:"new_state"`
+	Result   models.Result       `json:"last_result"`
+	Time     int64               `json:"time"`
+}
+
+// NewEvent returns an event for a healthcheck that changed state from oldState. The check's request headers
+// and body are left out as they can hold credentials, ie) an Authorization header
+func NewEvent(hc *models.HealthCheck, oldState string) Event {
+	check := hc.Copy()
+	check.Headers = nil
+	check.Body = ""
+	return Event{
+		Check:    check,
+		OldState: oldState,
+		NewState: hc.State,
+		Result:   hc.Result.Copy(),
+		Time:     hc.LastTransition,
+	}
+}
+
+// Notifier delivers events to an external service
+type Notifier interface {
+	// Name identifies the notifier in the delivery log
+	Name() string
+	// Notify delivers the event, retrying if needed, until it succeeds or ctx is done
+	Notify(ctx context.Context, e Event) error
+}
+
+// Batcher is a notifier that delivers the events queued within its window together, ie) as an email digest
+type Batcher interface {
+	Notifier
+	// BatchWindow is how long to wait for more events after the first, 0 disables batching
+	BatchWindow() time.Duration
+	// NotifyBatch delivers the events in the order they were sent
+	NotifyBatch(ctx context.Context, events []Event) error
+}
+
+// Dispatcher fans events out to notifiers. Each notifier has its own queue so a slow or retrying notifier
+// does not hold up the others
+type Dispatcher struct {
+	notifiers []Notifier
+	queues    []chan Event
+	log       *DeliveryLog
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// NewDispatcher returns a dispatcher that records every delivery in deliveries
+func NewDispatcher(deliveries *DeliveryLog, notifiers ...Notifier) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		notifiers: notifiers,
+		log:       deliveries,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	for range notifiers {
+		d.queues = append(d.queues, make(chan Event, queueSize))
+	}
+	return d
+}
+
+// Start starts delivering queued events
+func (d *Dispatcher) Start() {
+	for i, n := range d.notifiers {
+		d.wg.Add(1)
+		go d.deliver(n, d.queues[i])
+	}
+}
+
+// Send queues an event for every notifier, events are dropped if a notifier's queue is full
+func (d *Dispatcher) Send(e Event) {
+	for i, q := range d.queues {
+		select {
+		case q <- e:
+		default:
+			log.Printf("notification queue for %s is full, dropping event for healthcheck %s", d.notifiers[i].Name(), e.Check.ID)
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(n Notifier, queue chan Event) {
+	defer d.wg.Done()
+	if b, ok := n.(Batcher); ok && b.BatchWindow() > 0 {
+		d.deliverBatches(b, queue)
+		return
+	}
+	for {
+		select {
+		case e := <-queue:
+			start := time.Now()
+			err := n.Notify(d.ctx, e)
+			d.log.Add(newDelivery(n.Name(), e, start, err))
+			if err != nil && err != ErrIgnored {
+				log.Printf("unable to notify %s for healthcheck %s, err: %s", n.Name(), e.Check.ID, err)
+			}
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+// deliverBatches collects the events queued within the window after the first one and delivers them together
+func (d *Dispatcher) deliverBatches(b Batcher, queue chan Event) {
+	for {
+		var batch []Event
+		select {
+		case e := <-queue:
+			batch = append(batch, e)
+		case <-d.ctx.Done():
+			return
+		}
+
+		timer := time.NewTimer(b.BatchWindow())
+	collect:
+		for {
+			select {
+			case e := <-queue:
+				batch = append(batch, e)
+			case <-timer.C:
+				break collect
+			case <-d.ctx.Done():
+				timer.Stop()
+				log.Printf("dropping %d batched events for %s", len(batch), b.Name())
+				return
+			}
+		}
+
+		start := time.Now()
+		err := b.NotifyBatch(d.ctx, batch)
+		for _, e := range batch {
+			d.log.Add(newDelivery(b.Name(), e, start, err))
+		}
+		if err != nil {
+			log.Printf("unable to notify %s for %d healthchecks, err: %s", b.Name(), len(batch), err)
+		}
+	}
+}
+
+// withRetries calls send until it succeeds, returns a failure that should not be retried, or maxRetries is
+// reached, doubling the wait from backoff between attempts
+func withRetries(ctx context.Context, maxRetries int, backoff time.Duration, send func() (bool, error)) error {
+	for attempt := 0; ; attempt++ {
+		retry, err := send()
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= maxRetries {
+			return fmt.Errorf("after %d attempts: %s", attempt+1, err)
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return fmt.Errorf("after %d attempts: %s", attempt+1, ctx.Err())
+		}
+	}
+}
+
+// Stop stops delivering events, deliveries in progress are cancelled
+func (d *Dispatcher) Stop() {
+	log.Print("Stopping notification dispatcher")
+	d.cancel()
+	d.wg.Wait()
+}
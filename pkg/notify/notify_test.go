@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeNotifier struct {
+	name string
+	err  error
+	sent chan Event
+}
+
+func (fn *fakeNotifier) Name() string {
+	return fn.name
+}
+
+func (fn *fakeNotifier) Notify(ctx context.Context, e Event) error {
+	fn.sent <- e
+	return fn.err
+}
+
+func TestDispatcher(t *testing.T) {
+	ok := &fakeNotifier{name: "ok", sent: make(chan Event, 1)}
+	failing := &fakeNotifier{name: "failing", err: errors.New("unavailable"), sent: make(chan Event, 1)}
+	ignoring := &fakeNotifier{name: "ignoring", err: ErrIgnored, sent: make(chan Event, 1)}
+
+	deliveries := NewDeliveryLog(10)
+	d := NewDispatcher(deliveries, ok, failing, ignoring)
+	d.Start()
+	d.Send(testEvent())
+
+	for _, n := range []*fakeNotifier{ok, failing, ignoring} {
+		select {
+		case <-n.sent:
+		case <-time.After(time.Second):
+			t.Fatalf("expected %s to be notified", n.name)
+		}
+	}
+	d.Stop()
+
+	got := map[string]Delivery{}
+	for _, delivery := range deliveries.List() {
+		got[delivery.Notifier] = delivery
+	}
+	if !got["ok"].Success || got["failing"].Success || got["failing"].Error != "unavailable" {
+		t.Errorf("unexpected deliveries %+v", got)
+	}
+	if ignored := got["ignoring"]; ignored.Success || !ignored.Ignored || ignored.Error != "" {
+		t.Errorf("expected the ignored event to be logged as ignored, got %+v", ignored)
+	}
+}
+
+func TestDeliveryLog(t *testing.T) {
+	l := NewDeliveryLog(2)
+	l.Add(Delivery{Notifier: "a"})
+	l.Add(Delivery{Notifier: "b"})
+	l.Add(Delivery{Notifier: "c"})
+
+	got := l.List()
+	if len(got) != 2 || got[0].Notifier != "c" || got[1].Notifier != "b" {
+		t.Errorf("expected newest 2 deliveries, got %+v", got)
+	}
+}
@@ -0,0 +1,186 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+// DefaultPagerDutyURL is the base url of the PagerDuty Events API v2
+const DefaultPagerDutyURL = "https://events.pagerduty.com"
+
+// PagerDutyEvent is an Events API v2 event
+type PagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Client      string            `json:"client,omitempty"`
+	Payload     *PagerDutyPayload `json:"payload,omitempty"`
+}
+
+// PagerDutyPayload describes the incident, it is only sent with trigger events
+type PagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Timestamp     string                 `json:"timestamp,omitempty"`
+	Component     string                 `json:"component,omitempty"`
+	Class         string                 `json:"class,omitempty"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+// PagerDutyResponse is the Events API v2 response
+type PagerDutyResponse struct {
+	Status   string   `json:"status"`
+	Message  string   `json:"message"`
+	DedupKey string   `json:"dedup_key,omitempty"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// PagerDuty triggers an incident when a healthcheck goes DOWN and resolves it when the healthcheck recovers
+type PagerDuty struct {
+	name           string
+	url            string
+	routingKey     string
+	maxRetries     int
+	initialBackoff time.Duration
+	client         *http.Client
+}
+
+// NewPagerDuty returns a PagerDuty notifier, the url defaults to DefaultPagerDutyURL
+func NewPagerDuty(cfg Config) (*PagerDuty, error) {
+	if cfg.RoutingKey == "" {
+		return nil, fmt.Errorf("pagerduty %s requires a routing_key", cfg.Name)
+	}
+
+	p := &PagerDuty{
+		name:           cfg.Name,
+		url:            DefaultPagerDutyURL,
+		routingKey:     cfg.RoutingKey,
+		maxRetries:     defaultMaxRetries,
+		initialBackoff: defaultInitialBackoff,
+		client:         &http.Client{Timeout: defaultWebhookTimeout},
+	}
+	if cfg.URL != "" {
+		p.url = strings.TrimSuffix(cfg.URL, "/")
+	}
+	if cfg.MaxRetries != nil {
+		p.maxRetries = *cfg.MaxRetries
+	}
+	if cfg.InitialBackoff != "" {
+		backoff, err := time.ParseDuration(cfg.InitialBackoff)
+		if err != nil {
+			return nil, fmt.Errorf("pagerduty %s has an invalid initial_backoff: %s", cfg.Name, err)
+		}
+		p.initialBackoff = backoff
+	}
+	return p, nil
+}
+
+// Name returns the notifier's name
+func (p *PagerDuty) Name() string {
+	return p.name
+}
+
+// DedupKey is the key incidents for a healthcheck are grouped under
+func DedupKey(id string) string {
+	return "healthcheck-" + id
+}
+
+// Notify sends a trigger event when a healthcheck goes DOWN and a resolve event when it goes UP or DEGRADED,
+// whatever state it recovered from. Resolving a healthcheck without an open incident is a no-op in
+// PagerDuty. Other state changes are ignored and return ErrIgnored
+func (p *PagerDuty) Notify(ctx context.Context, e Event) error {
+	pe, ok := p.event(e)
+	if !ok {
+		return ErrIgnored
+	}
+
+	body, err := json.Marshal(pe)
+	if err != nil {
+		return err
+	}
+	return withRetries(ctx, p.maxRetries, p.initialBackoff, func() (bool, error) {
+		return p.post(ctx, body)
+	})
+}
+
+// event builds the Events API event for a state change, returning false if the change is not sent
+func (p *PagerDuty) event(e Event) (*PagerDutyEvent, bool) {
+	pe := &PagerDutyEvent{
+		RoutingKey: p.routingKey,
+		DedupKey:   DedupKey(e.Check.ID),
+		Client:     "healthcheck",
+	}
+
+	switch {
+	case e.NewState == models.StateDown:
+		pe.EventAction = "trigger"
+		pe.Payload = &PagerDutyPayload{
+			Summary:   fmt.Sprintf("%s is DOWN: %s", e.Check.Endpoint, summaryReason(e.Result)),
+			Source:    e.Check.Endpoint,
+			Severity:  "critical",
+			Timestamp: time.Unix(e.Time, 0).UTC().Format(time.RFC3339),
+			Component: e.Check.ID,
+			Class:     "healthcheck",
+			CustomDetails: map[string]interface{}{
+				"old_state": e.OldState,
+				"status":    e.Result.Status,
+				"code":      e.Result.Code,
+				"duration":  e.Result.Duration,
+				"error":     e.Result.Error,
+			},
+		}
+	case e.NewState == models.StateUp || e.NewState == models.StateDegraded:
+		pe.EventAction = "resolve"
+	default:
+		return nil, false
+	}
+	return pe, true
+}
+
+func summaryReason(r models.Result) string {
+	if r.Error != "" {
+		return r.Error
+	}
+	return r.Status
+}
+
+// post enqueues a single event, returning whether a failure should be retried
+func (p *PagerDuty) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, p.url+"/v2/enqueue", bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(ioutil.Discard, resp.Body)
+		return false, nil
+	}
+
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	var pr PagerDutyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil || pr.Message == "" {
+		return retry, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if len(pr.Errors) > 0 {
+		return retry, fmt.Errorf("unexpected status %s: %s: %s", resp.Status, pr.Message, strings.Join(pr.Errors, ", "))
+	}
+	return retry, fmt.Errorf("unexpected status %s: %s", resp.Status, pr.Message)
+}
@@ -0,0 +1,138 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+// fakePagerDuty stands in for the Events API v2, responding with statuses in order before accepting events
+type fakePagerDuty struct {
+	sync.Mutex
+	statuses []int
+	events   []PagerDutyEvent
+}
+
+func (f *fakePagerDuty) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	if r.Method != http.MethodPost || r.URL.Path != "/v2/enqueue" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var e PagerDutyEvent
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil || e.RoutingKey == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(PagerDutyResponse{Status: "invalid event", Message: "Event object is invalid", Errors: []string{"Length of 'routing_key' is incorrect"}})
+		return
+	}
+	if len(f.statuses) > 0 {
+		status := f.statuses[0]
+		f.statuses = f.statuses[1:]
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(PagerDutyResponse{Status: "throttle event", Message: "Requests for this service are arriving too quickly"})
+		return
+	}
+
+	f.events = append(f.events, e)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(PagerDutyResponse{Status: "success", Message: "Event processed", DedupKey: e.DedupKey})
+}
+
+func TestPagerDuty_Notify(t *testing.T) {
+	down := testEvent()
+	recovered := testEvent()
+	recovered.OldState, recovered.NewState = models.StateDown, models.StateUp
+	flapping := testEvent()
+	flapping.OldState, flapping.NewState = models.StateUp, models.StateFlapping
+	// recoveries that went through another state after DOWN still resolve
+	fromUnknown := testEvent()
+	fromUnknown.OldState, fromUnknown.NewState = models.StateUnknown, models.StateUp
+	fromUnreachable := testEvent()
+	fromUnreachable.OldState, fromUnreachable.NewState = models.StateUnreachable, models.StateUp
+	fromFlapping := testEvent()
+	fromFlapping.OldState, fromFlapping.NewState = models.StateFlapping, models.StateDegraded
+
+	tests := []struct {
+		name        string
+		event       Event
+		routingKey  string
+		statuses    []int
+		wantAction  string
+		wantErr     bool
+		wantIgnored bool
+	}{
+		{name: "down triggers", event: down, routingKey: "R0UT1NGKEY", wantAction: "trigger"},
+		{name: "recovery resolves", event: recovered, routingKey: "R0UT1NGKEY", wantAction: "resolve"},
+		{name: "recovery from unknown resolves", event: fromUnknown, routingKey: "R0UT1NGKEY", wantAction: "resolve"},
+		{name: "recovery from unreachable resolves", event: fromUnreachable, routingKey: "R0UT1NGKEY", wantAction: "resolve"},
+		{name: "degraded from flapping resolves", event: fromFlapping, routingKey: "R0UT1NGKEY", wantAction: "resolve"},
+		{name: "other changes are ignored", event: flapping, routingKey: "R0UT1NGKEY", wantErr: true, wantIgnored: true},
+		{name: "throttled is retried", event: down, routingKey: "R0UT1NGKEY", statuses: []int{http.StatusTooManyRequests}, wantAction: "trigger"},
+		{name: "retries exhausted", event: down, routingKey: "R0UT1NGKEY", statuses: []int{500, 500}, wantErr: true},
+		{name: "invalid event", event: down, routingKey: " ", statuses: []int{http.StatusBadRequest}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakePagerDuty{statuses: tt.statuses}
+			s := httptest.NewServer(fake)
+			defer s.Close()
+
+			maxRetries := 1
+			p, err := NewPagerDuty(Config{Name: "pager", URL: s.URL + "/", RoutingKey: tt.routingKey, MaxRetries: &maxRetries, InitialBackoff: "1ms"})
+			if err != nil {
+				t.Fatalf("NewPagerDuty() error = %v", err)
+			}
+
+			err = p.Notify(context.Background(), tt.event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Notify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (err == ErrIgnored) != tt.wantIgnored {
+				t.Errorf("Notify() error = %v, wantIgnored %v", err, tt.wantIgnored)
+			}
+			if tt.wantAction == "" {
+				if len(fake.events) != 0 {
+					t.Errorf("expected no events, got %+v", fake.events)
+				}
+				return
+			}
+			if len(fake.events) != 1 {
+				t.Fatalf("expected 1 event, got %d", len(fake.events))
+			}
+
+			got := fake.events[0]
+			if got.EventAction != tt.wantAction || got.RoutingKey != tt.routingKey || got.DedupKey != "healthcheck-C6C5B3DC-6685-7698-3CD5-C3AB7C10B3AC" {
+				t.Errorf("unexpected event %+v", got)
+			}
+			if tt.wantAction == "trigger" {
+				if got.Payload == nil || got.Payload.Severity != "critical" || got.Payload.Source != "https://www.blizzard.com/en-us/" ||
+					got.Payload.Summary != "https://www.blizzard.com/en-us/ is DOWN: unexpected status code 500" {
+					t.Errorf("unexpected payload %+v", got.Payload)
+				}
+			} else if got.Payload != nil {
+				t.Errorf("expected resolve without a payload, got %+v", got.Payload)
+			}
+		})
+	}
+}
+
+func TestNewPagerDuty(t *testing.T) {
+	if _, err := New(Config{Name: "pager", Type: "pagerduty"}); err == nil {
+		t.Error("expected an error without a routing_key")
+	}
+	n, err := New(Config{Name: "pager", Type: "pagerduty", RoutingKey: "R0UT1NGKEY"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if p := n.(*PagerDuty); p.url != DefaultPagerDutyURL {
+		t.Errorf("url = %s, want %s", p.url, DefaultPagerDutyURL)
+	}
+}
@@ -0,0 +1,259 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var defaultSMTPTimeout = 30 * time.Second
+
+var textEmail = template.Must(template.New("text").Funcs(templateFuncs).Parse(
+	`{{ range . }}{{ .Check.Endpoint }} is {{ .NewState }}, it was {{ .OldState }}
+  Time:     {{ unixTime .Time }}
+  Status:   {{ .Result.Status }} ({{ .Result.Code }})
+  Duration: {{ .Result.Duration }}
+{{ if .Result.Error }}  Error:    {{ .Result.Error }}
+{{ end }}
+{{ end }}`))
+
+var htmlEmail = htmltemplate.Must(htmltemplate.New("html").Funcs(htmltemplate.FuncMap(templateFuncs)).Parse(
+	`<html><body>
+<table cellpadding="4">
+<tr><th align="left">Endpoint</th><th align="left">State</th><th align="left">Status</th><th align="left">Duration</th><th align="left">Error</th><th align="left">Time</th></tr>
+{{ range . }}<tr><td>{{ .Check.Endpoint }}</td><td style="color: {{ color .NewState }}">{{ .OldState }} &rarr; {{ .NewState }}</td><td>{{ .Result.Status }} ({{ .Result.Code }})</td><td>{{ .Result.Duration }}</td><td>{{ .Result.Error }}</td><td>{{ unixTime .Time }}</td></tr>
+{{ end }}</table>
+</body></html>
+`))
+
+// SMTPConfig configures the email notifier
+type SMTPConfig struct {
+	Host string
+	Port int
+	// StartTLS upgrades the connection before authenticating, the server must support it
+	StartTLS bool
+	// Username and Password authenticate with PLAIN auth when Username is set
+	Username string
+	Password string
+	From     string
+	To       []string
+	// Digest is how long to wait for more state changes after the first before sending, 0 sends an email
+	// per state change
+	Digest         time.Duration
+	MaxRetries     *int
+	InitialBackoff time.Duration
+}
+
+// SMTP emails state changes, changes within the digest window are sent in a single email
+type SMTP struct {
+	host           string
+	port           int
+	startTLS       bool
+	tlsConfig      *tls.Config
+	auth           smtp.Auth
+	from           string
+	to             []string
+	digest         time.Duration
+	maxRetries     int
+	initialBackoff time.Duration
+	timeout        time.Duration
+}
+
+// NewSMTP returns an email notifier
+func NewSMTP(cfg SMTPConfig) (*SMTP, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("smtp notifier requires a host")
+	}
+	if cfg.From == "" || len(cfg.To) == 0 {
+		return nil, fmt.Errorf("smtp notifier requires from and to addresses")
+	}
+	if cfg.Digest < 0 {
+		return nil, fmt.Errorf("smtp notifier has a negative digest window")
+	}
+
+	s := &SMTP{
+		host:           cfg.Host,
+		port:           cfg.Port,
+		startTLS:       cfg.StartTLS,
+		tlsConfig:      &tls.Config{ServerName: cfg.Host},
+		from:           cfg.From,
+		to:             cfg.To,
+		digest:         cfg.Digest,
+		maxRetries:     defaultMaxRetries,
+		initialBackoff: defaultInitialBackoff,
+		timeout:        defaultSMTPTimeout,
+	}
+	if s.port == 0 {
+		s.port = 587
+	}
+	if cfg.Username != "" {
+		s.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	if cfg.MaxRetries != nil {
+		s.maxRetries = *cfg.MaxRetries
+	}
+	if cfg.InitialBackoff > 0 {
+		s.initialBackoff = cfg.InitialBackoff
+	}
+	return s, nil
+}
+
+// Name returns the notifier's name
+func (s *SMTP) Name() string {
+	return "smtp"
+}
+
+// BatchWindow returns the digest window
+func (s *SMTP) BatchWindow() time.Duration {
+	return s.digest
+}
+
+// Notify emails a single state change
+func (s *SMTP) Notify(ctx context.Context, e Event) error {
+	return s.NotifyBatch(ctx, []Event{e})
+}
+
+// NotifyBatch emails the state changes in one message. Connection errors and 4xx replies are retried
+func (s *SMTP) NotifyBatch(ctx context.Context, events []Event) error {
+	msg, err := s.message(events, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return withRetries(ctx, s.maxRetries, s.initialBackoff, func() (bool, error) {
+		err := s.send(ctx, msg)
+		return err != nil && retryable(err), err
+	})
+}
+
+// retryable reports whether an smtp error is temporary, permanent failures are 5xx replies
+func retryable(err error) bool {
+	if tpErr, ok := err.(*textproto.Error); ok {
+		return tpErr.Code >= 400 && tpErr.Code < 500
+	}
+	return true
+}
+
+// send delivers a message in a single smtp session
+func (s *SMTP) send(ctx context.Context, msg []byte) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(s.host, strconv.Itoa(s.port)))
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(s.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+
+	// abort the session if ctx is cancelled part way through
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if s.startTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp server %s does not support STARTTLS", s.host)
+		}
+		if err := c.StartTLS(s.tlsConfig); err != nil {
+			return err
+		}
+	}
+	if s.auth != nil {
+		if err := c.Auth(s.auth); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(s.from); err != nil {
+		return err
+	}
+	for _, to := range s.to {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	wc, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := wc.Write(msg); err != nil {
+		return err
+	}
+	if err := wc.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message builds a multipart email with plain text and HTML parts listing the events
+func (s *SMTP) message(events []Event, now time.Time) ([]byte, error) {
+	subject := fmt.Sprintf("%d healthchecks changed state", len(events))
+	if len(events) == 1 {
+		subject = fmt.Sprintf("%s is %s", events[0].Check.Endpoint, events[0].NewState)
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "From: %s\r\n", s.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(s.to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "[healthcheck] "+subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+
+	parts := []struct {
+		contentType string
+		execute     func(w *quotedprintable.Writer) error
+	}{
+		{"text/plain", func(w *quotedprintable.Writer) error { return textEmail.Execute(w, events) }},
+		{"text/html", func(w *quotedprintable.Writer) error { return htmlEmail.Execute(w, events) }},
+	}
+	for _, p := range parts {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if err := p.execute(qw); err != nil {
+			return nil, err
+		}
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,318 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+type fakeEmail struct {
+	from string
+	to   []string
+	auth string
+	tls  bool
+	data string
+}
+
+// fakeSMTPServer accepts mail on a local listener. Replies are taken from rcptReplies in order, once they
+// run out recipients are accepted
+type fakeSMTPServer struct {
+	sync.Mutex
+	listener    net.Listener
+	tlsConfig   *tls.Config
+	rcptReplies []string
+	emails      []fakeEmail
+}
+
+func newFakeSMTPServer(t *testing.T, tlsConfig *tls.Config, rcptReplies ...string) *fakeSMTPServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTPServer{listener: l, tlsConfig: tlsConfig, rcptReplies: rcptReplies}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) received() []fakeEmail {
+	s.Lock()
+	defer s.Unlock()
+	return append([]fakeEmail(nil), s.emails...)
+}
+
+func (s *fakeSMTPServer) nextRcptReply() string {
+	s.Lock()
+	defer s.Unlock()
+	if len(s.rcptReplies) == 0 {
+		return "250 OK"
+	}
+	reply := s.rcptReplies[0]
+	s.rcptReplies = s.rcptReplies[1:]
+	return reply
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		conn.Write([]byte(line + "\r\n"))
+	}
+
+	var email fakeEmail
+	reply("220 fake smtp")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch {
+		case cmd == "EHLO":
+			reply("250-fake")
+			if s.tlsConfig != nil && !email.tls {
+				reply("250-STARTTLS")
+			}
+			reply("250 AUTH PLAIN")
+		case cmd == "STARTTLS":
+			reply("220 ready")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			r = bufio.NewReader(conn)
+			email.tls = true
+		case cmd == "AUTH":
+			email.auth = line
+			reply("235 authenticated")
+		case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+			email.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+			reply("250 OK")
+		case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+			rcptReply := s.nextRcptReply()
+			if strings.HasPrefix(rcptReply, "250") {
+				email.to = append(email.to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+			}
+			reply(rcptReply)
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			email.data = data.String()
+			s.Lock()
+			s.emails = append(s.emails, email)
+			s.Unlock()
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+// parts returns the subject and the decoded body of each part keyed by content type
+func parts(t *testing.T, data string) (string, map[string]string) {
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("unexpected content type %s, err: %v", mediaType, err)
+	}
+
+	got := map[string]string{}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err != nil {
+			break
+		}
+		b, _ := ioutil.ReadAll(p)
+		contentType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		got[contentType] = string(b)
+	}
+	return subject, got
+}
+
+func TestSMTP_Notify(t *testing.T) {
+	ts := httptest.NewTLSServer(http.NotFoundHandler())
+	defer ts.Close()
+	serverTLS := &tls.Config{Certificates: ts.TLS.Certificates}
+	clientTLS := ts.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+	clientTLS.ServerName = "127.0.0.1"
+
+	tests := []struct {
+		name        string
+		startTLS    bool
+		serverTLS   *tls.Config
+		username    string
+		rcptReplies []string
+		wantErr     bool
+		wantEmails  int
+	}{
+		{name: "plain with auth", username: "ops", wantEmails: 1},
+		{name: "starttls", startTLS: true, serverTLS: serverTLS, username: "ops", wantEmails: 1},
+		{name: "starttls unsupported", startTLS: true, wantErr: true},
+		{name: "temporary failure is retried", rcptReplies: []string{"451 try again"}, wantEmails: 1},
+		{name: "permanent failure", rcptReplies: []string{"550 no such user"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTPServer(t, tt.serverTLS, tt.rcptReplies...)
+			defer server.listener.Close()
+
+			maxRetries := 1
+			s, err := NewSMTP(SMTPConfig{
+				Host:           "127.0.0.1",
+				Port:           server.port(),
+				StartTLS:       tt.startTLS,
+				Username:       tt.username,
+				Password:       "s3cret",
+				From:           "healthcheck@example.com",
+				To:             []string{"ops@example.com"},
+				MaxRetries:     &maxRetries,
+				InitialBackoff: time.Millisecond,
+			})
+			if err != nil {
+				t.Fatalf("NewSMTP() error = %v", err)
+			}
+			s.tlsConfig = clientTLS
+
+			err = s.Notify(context.Background(), testEvent())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Notify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			emails := server.received()
+			if len(emails) != tt.wantEmails {
+				t.Fatalf("expected %d emails, got %d", tt.wantEmails, len(emails))
+			}
+			if tt.wantEmails == 0 {
+				return
+			}
+
+			email := emails[0]
+			if email.from != "healthcheck@example.com" || len(email.to) != 1 || email.to[0] != "ops@example.com" {
+				t.Errorf("unexpected envelope %+v", email)
+			}
+			if email.tls != tt.startTLS {
+				t.Errorf("tls = %v, want %v", email.tls, tt.startTLS)
+			}
+			if (email.auth != "") != (tt.username != "") {
+				t.Errorf("auth = %q, want auth %v", email.auth, tt.username != "")
+			}
+
+			subject, body := parts(t, email.data)
+			if subject != "[healthcheck] https://www.blizzard.com/en-us/ is DOWN" {
+				t.Errorf("subject = %q", subject)
+			}
+			for _, contentType := range []string{"text/plain", "text/html"} {
+				for _, want := range []string{"https://www.blizzard.com/en-us/", "500 Internal Server Error (500)", "unexpected status code 500"} {
+					if !strings.Contains(body[contentType], want) {
+						t.Errorf("%s part = %q, want it to contain %q", contentType, body[contentType], want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSMTP_Digest(t *testing.T) {
+	server := newFakeSMTPServer(t, nil)
+	defer server.listener.Close()
+
+	s, err := NewSMTP(SMTPConfig{
+		Host:   "127.0.0.1",
+		Port:   server.port(),
+		From:   "healthcheck@example.com",
+		To:     []string{"ops@example.com", "oncall@example.com"},
+		Digest: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewSMTP() error = %v", err)
+	}
+
+	deliveries := NewDeliveryLog(10)
+	d := NewDispatcher(deliveries, s)
+	d.Start()
+	defer d.Stop()
+
+	d.Send(testEvent())
+	recovered := &models.HealthCheck{
+		ID:       "D1F5B3DC-6685-7698-3CD5-C3AB7C10B3AC",
+		Endpoint: "https://www.google.com",
+		Result:   models.Result{Status: "200 OK", Code: 200, Healthy: true},
+		Tracking: models.Tracking{State: models.StateUp},
+	}
+	d.Send(NewEvent(recovered, models.StateDown))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(deliveries.List()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	emails := server.received()
+	if len(emails) != 1 {
+		t.Fatalf("expected a single digest email, got %d", len(emails))
+	}
+	if len(emails[0].to) != 2 {
+		t.Errorf("expected 2 recipients, got %v", emails[0].to)
+	}
+	subject, body := parts(t, emails[0].data)
+	if subject != "[healthcheck] 2 healthchecks changed state" {
+		t.Errorf("subject = %q", subject)
+	}
+	for _, want := range []string{"https://www.blizzard.com/en-us/ is DOWN", "https://www.google.com is UP"} {
+		if !strings.Contains(body["text/plain"], want) {
+			t.Errorf("text part = %q, want it to contain %q", body["text/plain"], want)
+		}
+	}
+	for _, delivery := range deliveries.List() {
+		if !delivery.Success {
+			t.Errorf("unexpected failed delivery %+v", delivery)
+		}
+	}
+}
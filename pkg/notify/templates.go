@@ -0,0 +1,133 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+// built-in payload templates, selected with a notifier's format
+var formats = map[string]string{
+	"slack": `{
+  "text": {{ printf "%s %s is %s" (emoji .NewState) .Check.Endpoint .NewState | json }},
+  "attachments": [{
+    "color": {{ color .NewState | json }},
+    "fields": [
+      {"title": "Endpoint", "value": {{ json .Check.Endpoint }}, "short": false},
+      {"title": "State", "value": {{ printf "%s -> %s" .OldState .NewState | json }}, "short": true},
+      {"title": "Status", "value": {{ printf "%s (%d)" .Result.Status .Result.Code | json }}, "short": true},
+      {"title": "Duration", "value": {{ json .Result.Duration }}, "short": true}{{ if .Result.Error }},
+      {"title": "Error", "value": {{ json .Result.Error }}, "short": false}{{ end }}
+    ],
+    "ts": {{ .Time }}
+  }]
+}`,
+	"teams": `{
+  "@type": "MessageCard",
+  "@context": "https://schema.org/extensions",
+  "themeColor": {{ color .NewState | trimHash | json }},
+  "summary": {{ printf "%s is %s" .Check.Endpoint .NewState | json }},
+  "sections": [{
+    "activityTitle": {{ printf "%s %s is %s" (emoji .NewState) .Check.Endpoint .NewState | json }},
+    "activitySubtitle": {{ unixTime .Time | json }},
+    "facts": [
+      {"name": "Endpoint", "value": {{ json .Check.Endpoint }}},
+      {"name": "State", "value": {{ printf "%s -> %s" .OldState .NewState | json }}},
+      {"name": "Status", "value": {{ printf "%s (%d)" .Result.Status .Result.Code | json }}},
+      {"name": "Duration", "value": {{ json .Result.Duration }}}{{ if .Result.Error }},
+      {"name": "Error", "value": {{ json .Result.Error }}}{{ end }}
+    ]
+  }]
+}`,
+	"json": `{
+  "id": {{ json .Check.ID }},
+  "endpoint": {{ json .Check.Endpoint }},
+  "old_state": {{ json .OldState }},
+  "new_state": {{ json .NewState }},
+  "status": {{ json .Result.Status }},
+  "code": {{ .Result.Code }},
+  "duration": {{ json .Result.Duration }},
+  "error": {{ json .Result.Error }},
+  "time": {{ .Time }}
+}`,
+}
+
+var templateFuncs = template.FuncMap{
+	// json encodes a value, use it to embed strings in JSON payloads
+	"json": func(v interface{}) (string, error) {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(buf.String(), "\n"), nil
+	},
+	"color": func(state string) string {
+		switch state {
+		case models.StateUp:
+			return "#2eb886"
+		case models.StateDown:
+			return "#d00000"
+		default:
+			return "#daa038"
+		}
+	},
+	"emoji": func(state string) string {
+		switch state {
+		case models.StateUp:
+			return ":white_check_mark:"
+		case models.StateDown:
+			return ":red_circle:"
+		default:
+			return ":warning:"
+		}
+	},
+	"trimHash": func(s string) string {
+		if len(s) > 0 && s[0] == '#' {
+			return s[1:]
+		}
+		return s
+	},
+	"unixTime": func(unix int64) string {
+		return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+	},
+}
+
+// parseTemplate returns the template for a notifier, a custom template takes precedence over a built-in
+// format. Returns nil if neither is set
+func parseTemplate(name, format, text string) (*template.Template, error) {
+	if text == "" && format != "" {
+		var ok bool
+		if text, ok = formats[format]; !ok {
+			return nil, fmt.Errorf("notifier %s has an unknown format %q", name, format)
+		}
+	}
+	if text == "" {
+		return nil, nil
+	}
+
+	t, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("notifier %s has an invalid template: %s", name, err)
+	}
+	return t, nil
+}
+
+// render executes the template with the event, without a template the event is JSON encoded
+func render(t *template.Template, e Event) ([]byte, error) {
+	if t == nil {
+		return json.Marshal(e)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, e); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
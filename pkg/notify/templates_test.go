@@ -0,0 +1,141 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		template string
+		want     []string
+		wantJSON bool
+		wantErr  bool
+	}{
+		{
+			name:     "no template encodes the event",
+			want:     []string{`"new_state":"DOWN"`, `"endpoint":"https://www.blizzard.com/en-us/"`},
+			wantJSON: true,
+		},
+		{
+			name:     "slack",
+			format:   "slack",
+			want:     []string{`"text"`, `https://www.blizzard.com/en-us/`, `500 Internal Server Error (500)`, `unexpected status code 500`, `UP -> DOWN`, `#d00000`},
+			wantJSON: true,
+		},
+		{
+			name:     "teams",
+			format:   "teams",
+			want:     []string{`"@type": "MessageCard"`, `"themeColor": "d00000"`, `https://www.blizzard.com/en-us/`, `unexpected status code 500`, `2019-11-28T02:09:53Z`},
+			wantJSON: true,
+		},
+		{
+			name:     "json",
+			format:   "json",
+			want:     []string{`"code": 500`, `"error": "unexpected status code 500"`, `"old_state": "UP"`},
+			wantJSON: true,
+		},
+		{
+			name:     "custom template takes precedence",
+			format:   "slack",
+			template: `{{ .Check.Endpoint }} is {{ .NewState }}`,
+			want:     []string{`https://www.blizzard.com/en-us/ is DOWN`},
+		},
+		{
+			name:     "json func escapes strings",
+			template: `{"msg": {{ printf "%s \"quoted\"" .Result.Error | json }}}`,
+			want:     []string{`"unexpected status code 500 \"quoted\""`},
+			wantJSON: true,
+		},
+		{
+			name:     "execution error",
+			template: `{{ .Missing }}`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := parseTemplate("ops", tt.format, tt.template)
+			if err != nil {
+				t.Fatalf("parseTemplate() error = %v", err)
+			}
+			e := testEvent()
+			e.Time = 1574906993
+			b, err := render(tmpl, e)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(b), want) {
+					t.Errorf("render() = %s, want it to contain %s", b, want)
+				}
+			}
+			if tt.wantJSON && !json.Valid(b) {
+				t.Errorf("render() = %s, want valid JSON", b)
+			}
+		})
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		template string
+		wantNil  bool
+		wantErr  bool
+	}{
+		{name: "none", wantNil: true},
+		{name: "built-in", format: "teams"},
+		{name: "custom", template: `{{ .NewState }}`},
+		{name: "unknown format", format: "irc", wantErr: true},
+		{name: "invalid template", template: `{{ .NewState`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTemplate("ops", tt.format, tt.template)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("parseTemplate() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestWebhook_NotifyTemplate(t *testing.T) {
+	var body []byte
+	var contentType string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+		contentType = r.Header.Get("Content-Type")
+	}))
+	defer s.Close()
+
+	w, err := NewWebhook(Config{Name: "chat", URL: s.URL, Template: `{{ .Check.Endpoint }} is {{ .NewState }}`, ContentType: "text/plain"})
+	if err != nil {
+		t.Fatalf("NewWebhook() error = %v", err)
+	}
+	if err := w.Notify(context.Background(), testEvent()); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if got := string(body); got != "https://www.blizzard.com/en-us/ is DOWN" {
+		t.Errorf("body = %q", got)
+	}
+	if contentType != "text/plain" {
+		t.Errorf("Content-Type = %q, want text/plain", contentType)
+	}
+}
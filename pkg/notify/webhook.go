@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"text/template"
+	"time"
+)
+
+// SignatureHeader carries the hex encoded HMAC-SHA256 of the request body, keyed with the webhook secret
+const SignatureHeader = "X-Healthcheck-Signature"
+
+var (
+	defaultMaxRetries     = 3
+	defaultInitialBackoff = 1 * time.Second
+	defaultWebhookTimeout = 10 * time.Second
+)
+
+// Webhook POSTs events to a url, retrying failed deliveries with exponential backoff
+type Webhook struct {
+	name           string
+	url            string
+	secret         string
+	maxRetries     int
+	initialBackoff time.Duration
+	template       *template.Template
+	contentType    string
+	client         *http.Client
+}
+
+// NewWebhook returns a webhook notifier
+func NewWebhook(cfg Config) (*Webhook, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("webhook %s requires a url", cfg.Name)
+	}
+
+	w := &Webhook{
+		name:           cfg.Name,
+		url:            cfg.URL,
+		secret:         cfg.Secret,
+		maxRetries:     defaultMaxRetries,
+		initialBackoff: defaultInitialBackoff,
+		contentType:    "application/json",
+		client:         &http.Client{Timeout: defaultWebhookTimeout},
+	}
+	if cfg.ContentType != "" {
+		w.contentType = cfg.ContentType
+	}
+	if cfg.MaxRetries != nil {
+		w.maxRetries = *cfg.MaxRetries
+	}
+	if cfg.InitialBackoff != "" {
+		backoff, err := time.ParseDuration(cfg.InitialBackoff)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %s has an invalid initial_backoff: %s", cfg.Name, err)
+		}
+		w.initialBackoff = backoff
+	}
+
+	t, err := parseTemplate(cfg.Name, cfg.Format, cfg.Template)
+	if err != nil {
+		return nil, err
+	}
+	w.template = t
+	return w, nil
+}
+
+// Name returns the webhook's name
+func (w *Webhook) Name() string {
+	return w.name
+}
+
+// Notify POSTs the event rendered with the webhook's template, or as JSON without one. Network errors, 429s
+// and 5xxs are retried
+func (w *Webhook) Notify(ctx context.Context, e Event) error {
+	body, err := render(w.template, e)
+	if err != nil {
+		return err
+	}
+
+	return withRetries(ctx, w.maxRetries, w.initialBackoff, func() (bool, error) {
+		return w.post(ctx, body)
+	})
+}
+
+// post sends a single request, returning whether a failure should be retried
+func (w *Webhook) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", w.contentType)
+	if w.secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(w.secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("unexpected status %s", resp.Status)
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of body keyed with secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
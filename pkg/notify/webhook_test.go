@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+func testEvent() Event {
+	hc := &models.HealthCheck{
+		ID:       "C6C5B3DC-6685-7698-3CD5-C3AB7C10B3AC",
+		Endpoint: "https://www.blizzard.com/en-us/",
+		Result:   models.Result{Status: "500 Internal Server Error", Code: 500, Error: "unexpected status code 500"},
+		Tracking: models.Tracking{State: models.StateDown, LastTransition: 1574906993},
+	}
+	return NewEvent(hc, models.StateUp)
+}
+
+func TestWebhook_Notify(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		maxRetries   int
+		wantErr      bool
+		wantAttempts int32
+	}{
+		{
+			name:         "success",
+			statuses:     []int{http.StatusOK},
+			maxRetries:   3,
+			wantAttempts: 1,
+		},
+		{
+			name:         "retries server errors",
+			statuses:     []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusNoContent},
+			maxRetries:   3,
+			wantAttempts: 3,
+		},
+		{
+			name:         "gives up after max retries",
+			statuses:     []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			maxRetries:   2,
+			wantErr:      true,
+			wantAttempts: 3,
+		},
+		{
+			name:         "client errors are not retried",
+			statuses:     []int{http.StatusBadRequest, http.StatusOK},
+			maxRetries:   3,
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&attempts, 1)
+
+				body, _ := ioutil.ReadAll(r.Body)
+				if r.Header.Get(SignatureHeader) != "sha256="+Sign("s3cret", body) {
+					t.Error("expected a valid signature header")
+				}
+				e := Event{}
+				if err := json.Unmarshal(body, &e); err != nil || e.OldState != models.StateUp || e.NewState != models.StateDown || e.Result.Code != 500 {
+					t.Errorf("unexpected payload %s", body)
+				}
+
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer s.Close()
+
+			w, err := NewWebhook(Config{Name: "ops", URL: s.URL, Secret: "s3cret", MaxRetries: &tt.maxRetries, InitialBackoff: "1ms"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = w.Notify(context.Background(), testEvent())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Notify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.wantAttempts {
+				t.Errorf("got %d attempts, expected %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestNewEvent_Redacted(t *testing.T) {
+	hc := &models.HealthCheck{
+		ID:       "C6C5B3DC-6685-7698-3CD5-C3AB7C10B3AC",
+		Endpoint: "https://api.example.com/health",
+		Method:   http.MethodPost,
+		Headers:  map[string]string{"Authorization": "Bearer t0ken"},
+		Body:     `{"password": "hunter2"}`,
+		Tracking: models.Tracking{State: models.StateDown},
+	}
+
+	var body []byte
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer s.Close()
+
+	w, err := NewWebhook(Config{Name: "ops", URL: s.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Notify(context.Background(), NewEvent(hc, models.StateUp)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, secret := range []string{"Authorization", "t0ken", "hunter2", `"headers"`, `"body"`} {
+		if strings.Contains(string(body), secret) {
+			t.Errorf("expected %s to be left out of the payload %s", secret, body)
+		}
+	}
+	if hc.Headers == nil || hc.Body == "" {
+		t.Error("expected the healthcheck itself to be unchanged")
+	}
+}
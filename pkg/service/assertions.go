@@ -0,0 +1,173 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+// supported body assertion types
+const (
+	AssertContains    = "contains"
+	AssertNotContains = "not_contains"
+	AssertRegex       = "regex"
+	AssertJSONPath    = "jsonpath"
+)
+
+// ValidateAssertions checks that every assertion has a known type and a parsable regex or JSONPath
+func ValidateAssertions(assertions []models.Assertion) error {
+	for _, a := range assertions {
+		switch a.Type {
+		case AssertContains, AssertNotContains:
+			if a.Value == "" {
+				return fmt.Errorf("%s assertion requires a value", a.Type)
+			}
+		case AssertRegex:
+			if _, err := regexp.Compile(a.Value); err != nil {
+				return fmt.Errorf("invalid regex assertion: %s", err)
+			}
+		case AssertJSONPath:
+			if _, err := parseJSONPath(a.Path); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown assertion type %q", a.Type)
+		}
+	}
+	return nil
+}
+
+// checkAssertions runs every assertion against body, returning an error describing the first one that fails
+func checkAssertions(assertions []models.Assertion, body []byte) error {
+	var doc interface{}
+	var docErr error
+	parsed := false
+
+	for _, a := range assertions {
+		switch a.Type {
+		case AssertContains:
+			if !bytes.Contains(body, []byte(a.Value)) {
+				return fmt.Errorf("assertion failed: body does not contain %q", a.Value)
+			}
+		case AssertNotContains:
+			if bytes.Contains(body, []byte(a.Value)) {
+				return fmt.Errorf("assertion failed: body contains %q", a.Value)
+			}
+		case AssertRegex:
+			re, err := regexp.Compile(a.Value)
+			if err != nil {
+				return fmt.Errorf("assertion failed: %s", err)
+			}
+			if !re.Match(body) {
+				return fmt.Errorf("assertion failed: body does not match %q", a.Value)
+			}
+		case AssertJSONPath:
+			if !parsed {
+				docErr = json.Unmarshal(body, &doc)
+				parsed = true
+			}
+			if docErr != nil {
+				return fmt.Errorf("assertion failed: body is not valid JSON: %s", docErr)
+			}
+			got, err := evalJSONPath(a.Path, doc)
+			if err != nil {
+				return fmt.Errorf("assertion failed: %s", err)
+			}
+			if got != a.Value {
+				return fmt.Errorf("assertion failed: %s is %q, expected %q", a.Path, got, a.Value)
+			}
+		default:
+			return fmt.Errorf("assertion failed: unknown assertion type %q", a.Type)
+		}
+	}
+	return nil
+}
+
+// parseJSONPath splits a JSONPath expression into its object keys and array indexes. Only the child
+// operators are supported, ie) $.checks[0].status or $['status']
+func parseJSONPath(path string) ([]interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid jsonpath %q: must start with $", path)
+	}
+
+	var steps []interface{}
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid jsonpath %q: empty key", path)
+			}
+			steps = append(steps, rest[:end])
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("invalid jsonpath %q: unclosed bracket", path)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, inner[1:len(inner)-1])
+				continue
+			}
+			idx, err := strconv.Atoi(inner)
+			if err != nil || idx < 0 {
+				return nil, fmt.Errorf("invalid jsonpath %q: bad index %q", path, inner)
+			}
+			steps = append(steps, idx)
+		default:
+			return nil, fmt.Errorf("invalid jsonpath %q", path)
+		}
+	}
+
+	return steps, nil
+}
+
+// evalJSONPath resolves path against a decoded JSON document. Strings are returned as is, any other
+// value is returned JSON encoded, ie) true, 3, {"a":1}
+func evalJSONPath(path string, doc interface{}) (string, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	cur := doc
+	for _, step := range steps {
+		switch s := step.(type) {
+		case string:
+			obj, ok := cur.(map[string]interface{})
+			if !ok {
+				return "", fmt.Errorf("%s: %q is not an object key", path, s)
+			}
+			if cur, ok = obj[s]; !ok {
+				return "", fmt.Errorf("%s: key %q not found", path, s)
+			}
+		case int:
+			arr, ok := cur.([]interface{})
+			if !ok || s >= len(arr) {
+				return "", fmt.Errorf("%s: index %d out of range", path, s)
+			}
+			cur = arr[s]
+		}
+	}
+
+	if str, ok := cur.(string); ok {
+		return str, nil
+	}
+	b, err := json.Marshal(cur)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
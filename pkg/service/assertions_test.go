@@ -0,0 +1,118 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+func TestCheckAssertions(t *testing.T) {
+	body := []byte(`{"status":"ok","checks":[{"name":"db","up":true,"latency":3}]}`)
+	tests := []struct {
+		name       string
+		assertions []models.Assertion
+		wantErr    bool
+	}{
+		{
+			name:       "no assertions",
+			assertions: nil,
+		},
+		{
+			name:       "contains",
+			assertions: []models.Assertion{{Type: AssertContains, Value: `"ok"`}},
+		},
+		{
+			name:       "contains failure",
+			assertions: []models.Assertion{{Type: AssertContains, Value: "degraded"}},
+			wantErr:    true,
+		},
+		{
+			name:       "not contains",
+			assertions: []models.Assertion{{Type: AssertNotContains, Value: "degraded"}},
+		},
+		{
+			name:       "not contains failure",
+			assertions: []models.Assertion{{Type: AssertNotContains, Value: "db"}},
+			wantErr:    true,
+		},
+		{
+			name:       "regex",
+			assertions: []models.Assertion{{Type: AssertRegex, Value: `"latency":\d+`}},
+		},
+		{
+			name:       "regex failure",
+			assertions: []models.Assertion{{Type: AssertRegex, Value: `^<html>`}},
+			wantErr:    true,
+		},
+		{
+			name: "jsonpath",
+			assertions: []models.Assertion{
+				{Type: AssertJSONPath, Path: "$.status", Value: "ok"},
+				{Type: AssertJSONPath, Path: "$.checks[0].up", Value: "true"},
+				{Type: AssertJSONPath, Path: "$['checks'][0]['latency']", Value: "3"},
+			},
+		},
+		{
+			name:       "jsonpath mismatch",
+			assertions: []models.Assertion{{Type: AssertJSONPath, Path: "$.status", Value: "degraded"}},
+			wantErr:    true,
+		},
+		{
+			name:       "jsonpath missing key",
+			assertions: []models.Assertion{{Type: AssertJSONPath, Path: "$.checks[1].up", Value: "true"}},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAssertions(tt.assertions, body)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkAssertions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAssertions(t *testing.T) {
+	tests := []struct {
+		name       string
+		assertions []models.Assertion
+		wantErr    bool
+	}{
+		{
+			name: "valid",
+			assertions: []models.Assertion{
+				{Type: AssertContains, Value: "ok"},
+				{Type: AssertRegex, Value: "^ok$"},
+				{Type: AssertJSONPath, Path: "$.a[0]['b']", Value: "ok"},
+			},
+		},
+		{
+			name:       "unknown type",
+			assertions: []models.Assertion{{Type: "xpath", Value: "ok"}},
+			wantErr:    true,
+		},
+		{
+			name:       "bad regex",
+			assertions: []models.Assertion{{Type: AssertRegex, Value: "("}},
+			wantErr:    true,
+		},
+		{
+			name:       "bad jsonpath",
+			assertions: []models.Assertion{{Type: AssertJSONPath, Path: "status", Value: "ok"}},
+			wantErr:    true,
+		},
+		{
+			name:       "unclosed jsonpath",
+			assertions: []models.Assertion{{Type: AssertJSONPath, Path: "$.a[0", Value: "ok"}},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateAssertions(tt.assertions); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAssertions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
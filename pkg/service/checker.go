@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/labels"
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+// Checker performs healthchecks for an endpoint scheme
+type Checker interface {
+	// Validate returns an error if the healthcheck is not configured correctly for this checker
+	Validate(hc *models.HealthCheck) error
+	// Check runs the healthcheck and records the result on hc, ctx carries the check's timeout
+	Check(ctx context.Context, hc *models.HealthCheck) error
+}
+
+// checkers maps endpoint schemes to the checker that handles them
+var checkers = map[string]Checker{}
+
+// Register makes a checker available for endpoints with the given scheme
+func Register(scheme string, c Checker) {
+	checkers[strings.ToLower(scheme)] = c
+}
+
+func init() {
+	Register("http", httpChecker{})
+	Register("https", httpChecker{})
+	Register("tcp", tcpChecker{})
+	Register("dns", dnsChecker{})
+	Register("grpc", grpcChecker{})
+	Register(compositeScheme, compositeChecker{})
+}
+
+// checkerFor returns the checker registered for the endpoint's scheme
+func checkerFor(endpoint string) (Checker, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	c, ok := checkers[strings.ToLower(u.Scheme)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported endpoint scheme %q", u.Scheme)
+	}
+	return c, nil
+}
+
+// ValidateEndpoint checks an endpoint is a non empty url
+func ValidateEndpoint(endpoint string) error {
+	if endpoint == "" {
+		return fmt.Errorf("empty healthcheck endpoint")
+	}
+
+	if _, err := url.ParseRequestURI(endpoint); err != nil {
+		return fmt.Errorf("invalid URL")
+	}
+	return nil
+}
+
+// Validate returns an error if the healthcheck's endpoint scheme is not supported or its options are invalid
+func Validate(hc *models.HealthCheck) error {
+	c, err := checkerFor(hc.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	if err := validateDuration("max_latency", hc.MaxLatency); err != nil {
+		return err
+	}
+
+	if err := validateDuration("interval", hc.Interval); err != nil {
+		return err
+	}
+
+	if err := validateDuration("timeout", hc.Timeout); err != nil {
+		return err
+	}
+
+	if hc.FailureThreshold < 0 || hc.SuccessThreshold < 0 {
+		return fmt.Errorf("failure_threshold and success_threshold cannot be negative")
+	}
+
+	if err := labels.Validate(hc.Labels); err != nil {
+		return err
+	}
+
+	return c.Validate(hc)
+}
+
+// schemeOptions are the options only some schemes use, with whether each is set on a healthcheck
+var schemeOptions = []struct {
+	name string
+	set  func(hc *models.HealthCheck) bool
+}{
+	{"method", func(hc *models.HealthCheck) bool { return hc.Method != "" }},
+	{"headers", func(hc *models.HealthCheck) bool { return len(hc.Headers) != 0 }},
+	{"body", func(hc *models.HealthCheck) bool { return hc.Body != "" }},
+	{"expected_status", func(hc *models.HealthCheck) bool { return hc.ExpectedStatus != "" }},
+	{"assertions", func(hc *models.HealthCheck) bool { return len(hc.Assertions) != 0 }},
+	{"max_body_bytes", func(hc *models.HealthCheck) bool { return hc.MaxBodyBytes != 0 }},
+	{"cert_warn_days", func(hc *models.HealthCheck) bool { return hc.CertWarnDays != 0 }},
+	{"cert_fail_days", func(hc *models.HealthCheck) bool { return hc.CertFailDays != 0 }},
+	{"send", func(hc *models.HealthCheck) bool { return hc.Send != "" }},
+	{"expect", func(hc *models.HealthCheck) bool { return hc.Expect != "" }},
+	{"record_type", func(hc *models.HealthCheck) bool { return hc.RecordType != "" }},
+	{"expect_answers", func(hc *models.HealthCheck) bool { return len(hc.ExpectAnswers) != 0 }},
+	{"grpc_service", func(hc *models.HealthCheck) bool { return hc.GRPCService != "" }},
+	{"grpc_tls", func(hc *models.HealthCheck) bool { return hc.GRPCTLS }},
+	{"members", func(hc *models.HealthCheck) bool { return len(hc.Members) != 0 }},
+	{"member_selector", func(hc *models.HealthCheck) bool { return hc.MemberSelector != "" }},
+	{"min_up", func(hc *models.HealthCheck) bool { return hc.MinUp != 0 }},
+}
+
+// validateOptions returns an error naming the scheme specific options set on a healthcheck that its
+// scheme does not use, supported lists the ones it does
+func validateOptions(hc *models.HealthCheck, scheme string, supported ...string) error {
+	ok := make(map[string]bool, len(supported))
+	for _, name := range supported {
+		ok[name] = true
+	}
+
+	var unsupported []string
+	for _, o := range schemeOptions {
+		if !ok[o.name] && o.set(hc) {
+			unsupported = append(unsupported, o.name)
+		}
+	}
+	switch len(unsupported) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("%s is not supported for %s checks", unsupported[0], scheme)
+	}
+	last := len(unsupported) - 1
+	return fmt.Errorf("%s and %s are not supported for %s checks", strings.Join(unsupported[:last], ", "), unsupported[last], scheme)
+}
+
+// validateDuration checks that an optional duration option is positive
+func validateDuration(name string, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %s", name, err)
+	}
+	if d <= 0 {
+		return fmt.Errorf("%s must be positive", name)
+	}
+	return nil
+}
+
+// Timeout returns the healthcheck's own timeout, or the default timeout if it does not set one
+func Timeout(hc *models.HealthCheck) time.Duration {
+	if timeout, err := time.ParseDuration(hc.Timeout); err == nil && timeout > 0 {
+		return timeout
+	}
+	return defaultTimeout
+}
+
+// checkLatency fails a healthy check that took longer than its max latency
+func checkLatency(hc *models.HealthCheck, elapsed time.Duration) {
+	if !hc.Healthy || hc.MaxLatency == "" {
+		return
+	}
+
+	max, err := time.ParseDuration(hc.MaxLatency)
+	if err != nil {
+		return
+	}
+	if elapsed > max {
+		hc.Healthy = false
+		hc.Error = fmt.Sprintf("took %s, longer than max latency %s", elapsed, max)
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		hc      *models.HealthCheck
+		wantErr bool
+	}{
+		{
+			name: "http",
+			hc:   &models.HealthCheck{Endpoint: "https://www.blizzard.com", Method: "POST", Body: "{}"},
+		},
+		{
+			name:    "http invalid method",
+			hc:      &models.HealthCheck{Endpoint: "https://www.blizzard.com", Method: "CONNECT"},
+			wantErr: true,
+		},
+		{
+			name:    "http invalid header value",
+			hc:      &models.HealthCheck{Endpoint: "https://www.blizzard.com", Headers: map[string]string{"X-Token": "a\r\nb"}},
+			wantErr: true,
+		},
+		{
+			name: "tcp",
+			hc:   &models.HealthCheck{Endpoint: "tcp://localhost:6379", Send: "PING\r\n", Expect: "+PONG"},
+		},
+		{
+			name:    "tcp missing port",
+			hc:      &models.HealthCheck{Endpoint: "tcp://localhost"},
+			wantErr: true,
+		},
+		{
+			name:    "tcp with http options",
+			hc:      &models.HealthCheck{Endpoint: "tcp://localhost:6379", Method: "GET"},
+			wantErr: true,
+		},
+		{
+			name: "dns",
+			hc:   &models.HealthCheck{Endpoint: "dns://8.8.8.8/example.com", RecordType: "MX", MaxLatency: "200ms"},
+		},
+		{
+			name:    "dns missing name",
+			hc:      &models.HealthCheck{Endpoint: "dns://8.8.8.8"},
+			wantErr: true,
+		},
+		{
+			name:    "dns unsupported record type",
+			hc:      &models.HealthCheck{Endpoint: "dns://8.8.8.8/example.com", RecordType: "SRV"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid max latency",
+			hc:      &models.HealthCheck{Endpoint: "dns://8.8.8.8/example.com", MaxLatency: "fast"},
+			wantErr: true,
+		},
+		{
+			name:    "negative max latency",
+			hc:      &models.HealthCheck{Endpoint: "dns://8.8.8.8/example.com", MaxLatency: "-1s"},
+			wantErr: true,
+		},
+		{
+			name:    "zero max latency",
+			hc:      &models.HealthCheck{Endpoint: "dns://8.8.8.8/example.com", MaxLatency: "0s"},
+			wantErr: true,
+		},
+		{
+			name: "grpc",
+			hc:   &models.HealthCheck{Endpoint: "grpc://localhost:50051", GRPCService: "payments", GRPCTLS: true, Timeout: "3s"},
+		},
+		{
+			name:    "grpc missing port",
+			hc:      &models.HealthCheck{Endpoint: "grpc://localhost"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid timeout",
+			hc:      &models.HealthCheck{Endpoint: "grpc://localhost:50051", Timeout: "-1s"},
+			wantErr: true,
+		},
+		{
+			name:    "http with tcp options",
+			hc:      &models.HealthCheck{Endpoint: "https://www.blizzard.com", Send: "PING"},
+			wantErr: true,
+		},
+		{
+			name:    "tcp with cert expiry",
+			hc:      &models.HealthCheck{Endpoint: "tcp://localhost:6379", CertWarnDays: 14},
+			wantErr: true,
+		},
+		{
+			name:    "tcp with grpc options",
+			hc:      &models.HealthCheck{Endpoint: "tcp://localhost:6379", GRPCService: "payments"},
+			wantErr: true,
+		},
+		{
+			name:    "dns with max body bytes",
+			hc:      &models.HealthCheck{Endpoint: "dns://8.8.8.8/example.com", MaxBodyBytes: 1024},
+			wantErr: true,
+		},
+		{
+			name:    "grpc with tcp and dns options",
+			hc:      &models.HealthCheck{Endpoint: "grpc://localhost:50051", Send: "PING", RecordType: "A"},
+			wantErr: true,
+		},
+		{
+			name:    "members on a run check",
+			hc:      &models.HealthCheck{Endpoint: "grpc://localhost:50051", MinUp: 1},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported scheme",
+			hc:      &models.HealthCheck{Endpoint: "ftp://localhost"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.hc); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		hc   *models.HealthCheck
+		want string
+	}{
+		{name: "supported", hc: &models.HealthCheck{Send: "PING", Expect: "+PONG"}},
+		{name: "one", hc: &models.HealthCheck{Send: "PING", MaxBodyBytes: 10}, want: "max_body_bytes is not supported for tcp checks"},
+		{
+			name: "several",
+			hc:   &models.HealthCheck{Method: "GET", CertFailDays: 7, GRPCTLS: true},
+			want: "method, cert_fail_days and grpc_tls are not supported for tcp checks",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOptions(tt.hc, "tcp", "send", "expect")
+			if got := fmt.Sprint(err); (err != nil || tt.want != "") && got != tt.want {
+				t.Errorf("validateOptions() = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/labels"
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+const compositeScheme = "composite"
+
+var errCompositeCheck = errors.New("composite checks are evaluated from their members by the reporter")
+
+// compositeChecker validates composite://name checks. They are never run, the Reporter derives their result
+// from the states of their members
+type compositeChecker struct{}
+
+// Validate requires a name and members and rejects options for checks that are run
+func (compositeChecker) Validate(hc *models.HealthCheck) error {
+	u, err := url.Parse(hc.Endpoint)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("composite checks require a name, ie) composite://checkout")
+	}
+	if len(hc.Members) == 0 && hc.MemberSelector == "" {
+		return fmt.Errorf("composite checks require members or a member_selector")
+	}
+	if _, err := labels.Parse(hc.MemberSelector); err != nil {
+		return err
+	}
+	if hc.MinUp < 0 {
+		return fmt.Errorf("min_up cannot be negative")
+	}
+	if hc.MemberSelector == "" && hc.MinUp > len(hc.Members) {
+		return fmt.Errorf("min_up cannot be more than the %d members", len(hc.Members))
+	}
+
+	// composites are not run so they have no latency or timeout
+	if hc.MaxLatency != "" || hc.Timeout != "" {
+		return fmt.Errorf("max_latency and timeout are not supported for composite checks")
+	}
+	return validateOptions(hc, "composite", "members", "member_selector", "min_up")
+}
+
+// Check always fails, composite checks are not run
+func (compositeChecker) Check(ctx context.Context, hc *models.HealthCheck) error {
+	return errCompositeCheck
+}
+
+// isComposite returns whether the endpoint is a composite check
+func isComposite(endpoint string) bool {
+	return strings.HasPrefix(strings.ToLower(endpoint), compositeScheme+"://")
+}
+
+// isMember returns whether hc is one of a composite's members
+func isMember(composite, hc *models.HealthCheck) bool {
+	if composite.ID == hc.ID {
+		return false
+	}
+	for _, id := range composite.Members {
+		if id == hc.ID {
+			return true
+		}
+	}
+	if composite.MemberSelector == "" {
+		return false
+	}
+	selector, err := labels.Parse(composite.MemberSelector)
+	return err == nil && selector.Matches(hc.Labels)
+}
+
+// evaluateComposite sets a composite's result from the states of its members in checks, the composite is
+// healthy if at least min_up members are UP or DEGRADED. Listed members that no longer exist are not up
+func evaluateComposite(composite *models.HealthCheck, checks models.HealthChecks, now time.Time) {
+	byID := make(map[string]*models.HealthCheck, len(checks))
+	for _, hc := range checks {
+		byID[hc.ID] = hc
+	}
+
+	var members models.HealthChecks
+	var down []string
+	seen := map[string]bool{}
+	for _, id := range composite.Members {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		hc, ok := byID[id]
+		if !ok {
+			down = append(down, id+" (missing)")
+			continue
+		}
+		members = append(members, hc)
+	}
+	for _, hc := range checks {
+		if !seen[hc.ID] && isMember(composite, hc) {
+			seen[hc.ID] = true
+			members = append(members, hc)
+		}
+	}
+
+	up := 0
+	for _, hc := range members {
+		if isUp(hc.State) {
+			up++
+			continue
+		}
+		down = append(down, fmt.Sprintf("%s (%s)", hc.Endpoint, hc.State))
+	}
+
+	total := up + len(down)
+	required := composite.MinUp
+	if required == 0 {
+		required = total
+	}
+
+	composite.Result = models.Result{
+		Status:   fmt.Sprintf("%d/%d members up", up, total),
+		Healthy:  total > 0 && up >= required,
+		Checked:  now.Unix(),
+		Duration: time.Duration(0).String(),
+	}
+	switch {
+	case total == 0:
+		composite.Error = "composite check has no members"
+	case !composite.Healthy:
+		composite.Error = fmt.Sprintf("%d members up, %d required, not up: %s", up, required, strings.Join(down, ", "))
+	}
+}
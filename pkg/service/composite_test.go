@@ -0,0 +1,119 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+	"github.com/dnguy078/healthcheck/pkg/storage/mocks"
+)
+
+func TestCompositeChecker_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		hc      *models.HealthCheck
+		wantErr bool
+	}{
+		{name: "members", hc: &models.HealthCheck{Endpoint: "composite://checkout", Members: []string{"a", "b", "c"}, MinUp: 2}},
+		{name: "selector", hc: &models.HealthCheck{Endpoint: "composite://auth", MemberSelector: "service=auth"}},
+		{name: "no name", hc: &models.HealthCheck{Endpoint: "composite://", Members: []string{"a"}}, wantErr: true},
+		{name: "no members", hc: &models.HealthCheck{Endpoint: "composite://checkout"}, wantErr: true},
+		{name: "invalid selector", hc: &models.HealthCheck{Endpoint: "composite://auth", MemberSelector: "=auth"}, wantErr: true},
+		{name: "min_up above members", hc: &models.HealthCheck{Endpoint: "composite://checkout", Members: []string{"a"}, MinUp: 2}, wantErr: true},
+		{name: "negative min_up", hc: &models.HealthCheck{Endpoint: "composite://checkout", Members: []string{"a"}, MinUp: -1}, wantErr: true},
+		{name: "run options", hc: &models.HealthCheck{Endpoint: "composite://checkout", Members: []string{"a"}, Timeout: "1s"}, wantErr: true},
+		{name: "members on a http check", hc: &models.HealthCheck{Endpoint: "https://www.blizzard.com", Members: []string{"a"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.hc); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEvaluateComposite(t *testing.T) {
+	checks := models.HealthChecks{
+		&models.HealthCheck{ID: "us", Endpoint: "https://us.example.com", Labels: map[string]string{"service": "auth"}, Tracking: models.Tracking{State: models.StateUp}},
+		&models.HealthCheck{ID: "eu", Endpoint: "https://eu.example.com", Labels: map[string]string{"service": "auth"}, Tracking: models.Tracking{State: models.StateDegraded}},
+		&models.HealthCheck{ID: "ap", Endpoint: "https://ap.example.com", Tracking: models.Tracking{State: models.StateDown}},
+	}
+
+	tests := []struct {
+		name        string
+		composite   *models.HealthCheck
+		wantHealthy bool
+		wantStatus  string
+		wantError   string
+	}{
+		{name: "min up met", composite: &models.HealthCheck{Members: []string{"us", "eu", "ap"}, MinUp: 2}, wantHealthy: true, wantStatus: "2/3 members up"},
+		{name: "all required", composite: &models.HealthCheck{Members: []string{"us", "eu", "ap"}}, wantStatus: "2/3 members up", wantError: "2 members up, 3 required, not up: https://ap.example.com (DOWN)"},
+		{name: "selector", composite: &models.HealthCheck{MemberSelector: "service=auth"}, wantHealthy: true, wantStatus: "2/2 members up"},
+		{name: "ids and selector", composite: &models.HealthCheck{Members: []string{"ap", "us"}, MemberSelector: "service=auth", MinUp: 3}, wantStatus: "2/3 members up", wantError: "2 members up, 3 required, not up: https://ap.example.com (DOWN)"},
+		{name: "missing member", composite: &models.HealthCheck{Members: []string{"us", "gone"}}, wantStatus: "1/2 members up", wantError: "1 members up, 2 required, not up: gone (missing)"},
+		{name: "no members", composite: &models.HealthCheck{MemberSelector: "service=billing"}, wantStatus: "0/0 members up", wantError: "composite check has no members"},
+	}
+
+	now := time.Now()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.composite.ID = "composite"
+			evaluateComposite(tt.composite, checks, now)
+			if tt.composite.Healthy != tt.wantHealthy || tt.composite.Status != tt.wantStatus || tt.composite.Error != tt.wantError {
+				t.Errorf("got healthy %v status %q error %q", tt.composite.Healthy, tt.composite.Status, tt.composite.Error)
+			}
+			if tt.composite.Checked != now.Unix() {
+				t.Errorf("expected checked to be set")
+			}
+		})
+	}
+}
+
+func TestReporter_Composite(t *testing.T) {
+	db := &mocks.FakeCollection{
+		ListResp: models.HealthChecks{
+			&models.HealthCheck{ID: "us", Endpoint: "https://us.example.com", FailureThreshold: 1, Tracking: models.Tracking{State: models.StateUp}},
+			&models.HealthCheck{ID: "eu", Endpoint: "https://eu.example.com", FailureThreshold: 1, Tracking: models.Tracking{State: models.StateUp}},
+			&models.HealthCheck{ID: "checkout", Endpoint: "composite://checkout", Members: []string{"us", "eu"}, MinUp: 2, Tracking: models.Tracking{State: models.StateUp}},
+			// composites can be members of other composites
+			&models.HealthCheck{ID: "shop", Endpoint: "composite://shop", Members: []string{"checkout"}, Tracking: models.Tracking{State: models.StateUp}},
+		},
+	}
+	r, err := NewReporter(time.Hour, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Stop()
+	sender := &fakeSender{}
+	r.SetNotifier(sender)
+
+	r.process(&models.HealthCheck{ID: "eu", Result: models.Result{Healthy: false}})
+	for id, want := range map[string]string{"eu": models.StateDown, "checkout": models.StateDown, "shop": models.StateDown} {
+		if hc, _ := db.Get(id); hc.State != want {
+			t.Errorf("got %s state %s, expected %s", id, hc.State, want)
+		}
+	}
+
+	// a scheduled evaluation of an unchanged composite records a result without a transition
+	r.process(&models.HealthCheck{ID: "checkout"})
+	if got := db.RecordedCount("checkout"); got != 2 {
+		t.Errorf("expected checkout to be recorded twice, got %d", got)
+	}
+
+	r.process(&models.HealthCheck{ID: "eu", Result: models.Result{Healthy: true}})
+	if hc, _ := db.Get("shop"); hc.State != models.StateUp {
+		t.Errorf("expected shop to recover, got %s", hc.State)
+	}
+
+	var got []string
+	for _, e := range sender.events {
+		got = append(got, e.Check.ID+" "+e.NewState)
+	}
+	want := "eu DOWN, checkout DOWN, shop DOWN, eu UP, checkout UP, shop UP"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("got events %v, expected %s", got, want)
+	}
+}
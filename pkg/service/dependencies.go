@@ -0,0 +1,93 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+// ValidateParents checks a healthcheck's parents exist and that depending on them does not create a cycle.
+// checks are the stored healthchecks, hc takes the place of a stored check with the same id
+func ValidateParents(hc *models.HealthCheck, checks models.HealthChecks) error {
+	parents := make(map[string][]string, len(checks)+1)
+	for _, c := range checks {
+		parents[c.ID] = c.Parents
+	}
+	for _, id := range hc.Parents {
+		if id == hc.ID {
+			return fmt.Errorf("healthcheck cannot be its own parent")
+		}
+		if _, ok := parents[id]; !ok {
+			return fmt.Errorf("unknown parent %s", id)
+		}
+	}
+	parents[hc.ID] = hc.Parents
+
+	if cycle := findCycle(hc.ID, parents, []string{hc.ID}, map[string]bool{}); cycle != nil {
+		return fmt.Errorf("dependency cycle %s", strings.Join(cycle, " -> "))
+	}
+	return nil
+}
+
+// findCycle follows the edges, ie) parents or members, from the last id in path, returning the path once it
+// leads back to start
+func findCycle(start string, edges map[string][]string, path []string, visited map[string]bool) []string {
+	for _, id := range edges[path[len(path)-1]] {
+		if id == start {
+			return append(path, id)
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		if cycle := findCycle(start, edges, append(path, id), visited); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// ValidateMembers checks the members listed by a composite healthcheck exist and that it is not a member of
+// itself through other composites, listed or selected. checks are the stored healthchecks, hc takes the
+// place of a stored check with the same id
+func ValidateMembers(hc *models.HealthCheck, checks models.HealthChecks) error {
+	ids := make(map[string]bool, len(checks))
+	for _, c := range checks {
+		ids[c.ID] = true
+	}
+	for _, id := range hc.Members {
+		if id == hc.ID {
+			return fmt.Errorf("composite check cannot be its own member")
+		}
+		if !ids[id] {
+			return fmt.Errorf("unknown member %s", id)
+		}
+	}
+	if !isComposite(hc.Endpoint) {
+		return nil
+	}
+
+	all := models.HealthChecks{hc}
+	for _, c := range checks {
+		if c.ID != hc.ID {
+			all = append(all, c)
+		}
+	}
+	members := map[string][]string{}
+	for _, composite := range all {
+		if !isComposite(composite.Endpoint) {
+			continue
+		}
+		for _, c := range all {
+			if isMember(composite, c) {
+				members[composite.ID] = append(members[composite.ID], c.ID)
+			}
+		}
+	}
+
+	if cycle := findCycle(hc.ID, members, []string{hc.ID}, map[string]bool{}); cycle != nil {
+		return fmt.Errorf("member cycle %s", strings.Join(cycle, " -> "))
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+func TestValidateParents(t *testing.T) {
+	checks := models.HealthChecks{
+		&models.HealthCheck{ID: "lb"},
+		&models.HealthCheck{ID: "app", Parents: []string{"lb"}},
+		&models.HealthCheck{ID: "api", Parents: []string{"app", "lb"}},
+	}
+
+	tests := []struct {
+		name    string
+		hc      *models.HealthCheck
+		wantErr string
+	}{
+		{name: "no parents", hc: &models.HealthCheck{ID: "new"}},
+		{name: "new child", hc: &models.HealthCheck{ID: "new", Parents: []string{"api", "lb"}}},
+		{name: "unchanged", hc: &models.HealthCheck{ID: "api", Parents: []string{"app"}}},
+		{name: "unknown parent", hc: &models.HealthCheck{ID: "new", Parents: []string{"db"}}, wantErr: "unknown parent db"},
+		{name: "own parent", hc: &models.HealthCheck{ID: "app", Parents: []string{"app"}}, wantErr: "healthcheck cannot be its own parent"},
+		{name: "direct cycle", hc: &models.HealthCheck{ID: "lb", Parents: []string{"app"}}, wantErr: "dependency cycle lb -> app -> lb"},
+		{name: "indirect cycle", hc: &models.HealthCheck{ID: "lb", Parents: []string{"api"}}, wantErr: "dependency cycle lb -> api -> app -> lb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateParents(tt.hc, checks)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("ValidateParents() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("ValidateParents() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateMembers(t *testing.T) {
+	checks := models.HealthChecks{
+		&models.HealthCheck{ID: "web", Endpoint: "https://web", Labels: map[string]string{"tier": "web"}},
+		&models.HealthCheck{ID: "a", Endpoint: "composite://a", Members: []string{"web", "b"}},
+		&models.HealthCheck{ID: "b", Endpoint: "composite://b", Members: []string{"web"}, Labels: map[string]string{"tier": "edge"}},
+	}
+
+	tests := []struct {
+		name    string
+		hc      *models.HealthCheck
+		wantErr string
+	}{
+		{name: "new composite", hc: &models.HealthCheck{ID: "new", Endpoint: "composite://new", Members: []string{"a", "b"}}},
+		{name: "unchanged", hc: &models.HealthCheck{ID: "b", Endpoint: "composite://b", Members: []string{"web"}}},
+		{name: "unknown member", hc: &models.HealthCheck{ID: "new", Endpoint: "composite://new", Members: []string{"db"}}, wantErr: "unknown member db"},
+		{name: "own member", hc: &models.HealthCheck{ID: "a", Endpoint: "composite://a", Members: []string{"a"}}, wantErr: "composite check cannot be its own member"},
+		{name: "listed cycle", hc: &models.HealthCheck{ID: "b", Endpoint: "composite://b", Members: []string{"a"}}, wantErr: "member cycle b -> a -> b"},
+		{name: "selected cycle", hc: &models.HealthCheck{ID: "b", Endpoint: "composite://b", MemberSelector: "tier!=web"}, wantErr: "member cycle b -> a -> b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMembers(tt.hc, checks)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("ValidateMembers() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("ValidateMembers() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+var recordTypes = map[string]bool{
+	"A":     true,
+	"AAAA":  true,
+	"CNAME": true,
+	"TXT":   true,
+	"MX":    true,
+}
+
+// dnsChecker resolves dns://resolver:port/name endpoints against the given resolver
+type dnsChecker struct{}
+
+// Validate requires a resolver address and name and a supported record type
+func (dnsChecker) Validate(hc *models.HealthCheck) error {
+	if _, _, err := dnsTarget(hc.Endpoint); err != nil {
+		return err
+	}
+
+	if !recordTypes[recordType(hc)] {
+		return fmt.Errorf("unsupported record type %q", hc.RecordType)
+	}
+
+	return validateOptions(hc, "dns", "record_type", "expect_answers")
+}
+
+// Check resolves the name and verifies every expected answer was returned
+func (dnsChecker) Check(ctx context.Context, hc *models.HealthCheck) error {
+	server, name, err := dnsTarget(hc.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+
+	answers, err := lookup(ctx, resolver, recordType(hc), name)
+	if err != nil {
+		return err
+	}
+
+	hc.Status = "Resolved"
+	hc.Answers = answers
+	hc.Healthy = true
+
+	if missing := missingAnswers(hc.ExpectAnswers, answers); len(missing) != 0 {
+		hc.Healthy = false
+		hc.Error = fmt.Sprintf("missing expected answers %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// lookup resolves name for the record type, answers are sorted and names are fully qualified
+func lookup(ctx context.Context, resolver *net.Resolver, rtype string, name string) ([]string, error) {
+	var answers []string
+	switch rtype {
+	case "A", "AAAA":
+		network := "ip4"
+		if rtype == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIPAddr(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			if (ip.IP.To4() != nil) == (network == "ip4") {
+				answers = append(answers, ip.IP.String())
+			}
+		}
+		if len(answers) == 0 {
+			return nil, fmt.Errorf("no %s records found for %s", rtype, name)
+		}
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		// a name with address records but no CNAME resolves to itself without an error
+		if strings.EqualFold(strings.TrimSuffix(cname, "."), strings.TrimSuffix(name, ".")) {
+			return nil, fmt.Errorf("no CNAME records found for %s", name)
+		}
+		answers = append(answers, cname)
+	case "TXT":
+		txts, err := resolver.LookupTXT(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, txts...)
+	case "MX":
+		mxs, err := resolver.LookupMX(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			answers = append(answers, fmt.Sprintf("%d %s", mx.Pref, mx.Host))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported record type %q", rtype)
+	}
+
+	sort.Strings(answers)
+	return answers, nil
+}
+
+// missingAnswers returns the expected answers not found in answers, names are compared without the
+// trailing dot
+func missingAnswers(expected []string, answers []string) []string {
+	found := make(map[string]bool, len(answers))
+	for _, a := range answers {
+		found[strings.TrimSuffix(a, ".")] = true
+	}
+
+	var missing []string
+	for _, e := range expected {
+		if !found[strings.TrimSuffix(e, ".")] {
+			missing = append(missing, e)
+		}
+	}
+	return missing
+}
+
+// dnsTarget extracts the resolver address and name from a dns://resolver:port/name endpoint, the port
+// defaults to 53
+func dnsTarget(endpoint string) (string, string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", "", err
+	}
+
+	name := strings.Trim(u.Path, "/")
+	if u.Hostname() == "" || name == "" {
+		return "", "", fmt.Errorf("dns endpoint must be in the form dns://resolver:port/name")
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "53"
+	}
+	return net.JoinHostPort(u.Hostname(), port), name, nil
+}
+
+func recordType(hc *models.HealthCheck) string {
+	if hc.RecordType == "" {
+		return "A"
+	}
+	return strings.ToUpper(hc.RecordType)
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// startDNSServer serves a fixed zone for example.test. over udp, any other name is NXDOMAIN
+func startDNSServer(t *testing.T) net.PacketConn {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := dnsmessage.MustNewName("example.test.")
+	alias := dnsmessage.MustNewName("www.example.test.")
+	mail := dnsmessage.MustNewName("mail.example.test.")
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			var p dnsmessage.Parser
+			h, err := p.Start(buf[:n])
+			if err != nil {
+				continue
+			}
+			q, err := p.Question()
+			if err != nil {
+				continue
+			}
+
+			b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: h.ID, Response: true, Authoritative: true, RCode: dnsmessage.RCodeSuccess})
+			b.StartQuestions()
+			b.Question(q)
+			b.StartAnswers()
+
+			rh := func(name dnsmessage.Name, typ dnsmessage.Type) dnsmessage.ResourceHeader {
+				return dnsmessage.ResourceHeader{Name: name, Type: typ, Class: dnsmessage.ClassINET, TTL: 60}
+			}
+			switch {
+			case q.Name == name && q.Type == dnsmessage.TypeA:
+				b.AResource(rh(name, q.Type), dnsmessage.AResource{A: [4]byte{10, 0, 0, 1}})
+				b.AResource(rh(name, q.Type), dnsmessage.AResource{A: [4]byte{10, 0, 0, 2}})
+			case q.Name == name && q.Type == dnsmessage.TypeAAAA:
+				b.AAAAResource(rh(name, q.Type), dnsmessage.AAAAResource{AAAA: [16]byte{0xfd, 15: 1}})
+			case q.Name == name && q.Type == dnsmessage.TypeTXT:
+				b.TXTResource(rh(name, q.Type), dnsmessage.TXTResource{TXT: []string{"v=spf1 -all"}})
+			case q.Name == name && q.Type == dnsmessage.TypeMX:
+				b.MXResource(rh(name, q.Type), dnsmessage.MXResource{Pref: 10, MX: mail})
+			case q.Name == alias && q.Type == dnsmessage.TypeCNAME:
+				b.CNAMEResource(rh(alias, q.Type), dnsmessage.CNAMEResource{CNAME: name})
+			case q.Name != name && q.Name != alias:
+				b = dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: h.ID, Response: true, RCode: dnsmessage.RCodeNameError})
+				b.StartQuestions()
+				b.Question(q)
+			}
+
+			msg, err := b.Finish()
+			if err != nil {
+				continue
+			}
+			pc.WriteTo(msg, addr)
+		}
+	}()
+
+	return pc
+}
+
+func TestRun_DNS(t *testing.T) {
+	pc := startDNSServer(t)
+	defer pc.Close()
+	resolver := "dns://" + pc.LocalAddr().String()
+
+	tests := []struct {
+		name        string
+		endpoint    string
+		recordType  string
+		expect      []string
+		maxLatency  string
+		wantHealthy bool
+	}{
+		{
+			name:        "A",
+			endpoint:    resolver + "/example.test",
+			expect:      []string{"10.0.0.1", "10.0.0.2"},
+			wantHealthy: true,
+		},
+		{
+			name:        "A missing answer",
+			endpoint:    resolver + "/example.test",
+			expect:      []string{"10.0.0.3"},
+			wantHealthy: false,
+		},
+		{
+			name:        "AAAA",
+			endpoint:    resolver + "/example.test",
+			recordType:  "AAAA",
+			expect:      []string{"fd00::1"},
+			wantHealthy: true,
+		},
+		{
+			name:        "CNAME",
+			endpoint:    resolver + "/www.example.test",
+			recordType:  "CNAME",
+			expect:      []string{"example.test"},
+			wantHealthy: true,
+		},
+		{
+			name:        "no CNAME",
+			endpoint:    resolver + "/example.test",
+			recordType:  "CNAME",
+			wantHealthy: false,
+		},
+		{
+			name:        "TXT",
+			endpoint:    resolver + "/example.test",
+			recordType:  "TXT",
+			expect:      []string{"v=spf1 -all"},
+			wantHealthy: true,
+		},
+		{
+			name:        "MX",
+			endpoint:    resolver + "/example.test",
+			recordType:  "MX",
+			expect:      []string{"10 mail.example.test."},
+			wantHealthy: true,
+		},
+		{
+			name:        "nxdomain",
+			endpoint:    resolver + "/missing.test",
+			wantHealthy: false,
+		},
+		{
+			name:        "too slow",
+			endpoint:    resolver + "/example.test",
+			maxLatency:  "1ns",
+			wantHealthy: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hc := &models.HealthCheck{
+				Endpoint:      tt.endpoint,
+				RecordType:    tt.recordType,
+				ExpectAnswers: tt.expect,
+				MaxLatency:    tt.maxLatency,
+			}
+			if err := Validate(hc); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+
+			got := Run(hc, 2*time.Second)
+			if got.Healthy != tt.wantHealthy {
+				t.Errorf("got healthy %v, expected %v, answers: %v, err: %s", got.Healthy, tt.wantHealthy, got.Answers, got.Error)
+			}
+		})
+	}
+}
+
+func TestRun_DNSFailureClearsPreviousAnswers(t *testing.T) {
+	pc := startDNSServer(t)
+	defer pc.Close()
+	resolver := "dns://" + pc.LocalAddr().String()
+
+	hc := Run(&models.HealthCheck{Endpoint: resolver + "/example.test"}, 2*time.Second)
+	if !hc.Healthy || len(hc.Answers) == 0 {
+		t.Fatalf("expected a healthy result with answers, got %+v", hc.Result)
+	}
+
+	hc.Endpoint = resolver + "/missing.test"
+	got := Run(hc, 2*time.Second)
+	if got.Healthy {
+		t.Fatal("expected the failed lookup to be unhealthy")
+	}
+	if len(got.Answers) != 0 {
+		t.Errorf("expected no answers from a failed lookup, got %v", got.Answers)
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// grpcChecker calls the grpc.health.v1.Health/Check rpc on grpc://host:port endpoints
+type grpcChecker struct{}
+
+// Validate requires a host and port and rejects options the scheme does not use
+func (grpcChecker) Validate(hc *models.HealthCheck) error {
+	if _, err := grpcAddress(hc.Endpoint); err != nil {
+		return err
+	}
+
+	return validateOptions(hc, "grpc", "grpc_service", "grpc_tls")
+}
+
+// Check maps the serving status of the service onto the healthcheck, only SERVING is healthy
+func (grpcChecker) Check(ctx context.Context, hc *models.HealthCheck) error {
+	addr, err := grpcAddress(hc.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	creds := grpc.WithInsecure()
+	if hc.GRPCTLS {
+		creds = grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{}))
+	}
+
+	conn, err := grpc.DialContext(ctx, addr, creds, grpc.WithBlock())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: hc.GRPCService})
+	if err != nil {
+		return err
+	}
+
+	// code is an http status code and stays 0, the serving status is only kept in status
+	hc.Status = resp.Status.String()
+	hc.Healthy = resp.Status == healthpb.HealthCheckResponse_SERVING
+	if !hc.Healthy {
+		hc.Error = fmt.Sprintf("service is %s", resp.Status)
+	}
+	return nil
+}
+
+// grpcAddress extracts host:port from a grpc://host:port endpoint
+func grpcAddress(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	if u.Hostname() == "" || u.Port() == "" {
+		return "", fmt.Errorf("grpc endpoint must be in the form grpc://host:port")
+	}
+	return u.Host, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestRun_GRPC(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hs := health.NewServer()
+	hs.SetServingStatus("payments", healthpb.HealthCheckResponse_SERVING)
+	hs.SetServingStatus("checkout", healthpb.HealthCheckResponse_NOT_SERVING)
+	hs.SetServingStatus("search", healthpb.HealthCheckResponse_UNKNOWN)
+
+	s := grpc.NewServer()
+	healthpb.RegisterHealthServer(s, hs)
+	go s.Serve(l)
+	defer s.Stop()
+
+	endpoint := "grpc://" + l.Addr().String()
+	tests := []struct {
+		name        string
+		endpoint    string
+		service     string
+		wantStatus  string
+		wantHealthy bool
+	}{
+		{
+			name:        "server serving",
+			endpoint:    endpoint,
+			wantStatus:  "SERVING",
+			wantHealthy: true,
+		},
+		{
+			name:        "service serving",
+			endpoint:    endpoint,
+			service:     "payments",
+			wantStatus:  "SERVING",
+			wantHealthy: true,
+		},
+		{
+			name:        "service not serving",
+			endpoint:    endpoint,
+			service:     "checkout",
+			wantStatus:  "NOT_SERVING",
+			wantHealthy: false,
+		},
+		{
+			name:        "service unknown status",
+			endpoint:    endpoint,
+			service:     "search",
+			wantStatus:  "UNKNOWN",
+			wantHealthy: false,
+		},
+		{
+			name:        "unregistered service",
+			endpoint:    endpoint,
+			service:     "missing",
+			wantStatus:  "Error",
+			wantHealthy: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hc := &models.HealthCheck{
+				Endpoint:    tt.endpoint,
+				GRPCService: tt.service,
+			}
+
+			got := Run(hc, 2*time.Second)
+			if got.Healthy != tt.wantHealthy || got.Status != tt.wantStatus || got.Code != 0 {
+				t.Errorf("got healthy %v status %s code %d, expected %v %s 0, err: %s", got.Healthy, got.Status, got.Code, tt.wantHealthy, tt.wantStatus, got.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+	"golang.org/x/net/http/httpguts"
+)
+
+var allowedMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+// httpChecker performs http and https healthchecks
+type httpChecker struct{}
+
+// Validate validates the http method, headers, body, expected status codes and assertions and rejects
+// options for other schemes
+func (httpChecker) Validate(hc *models.HealthCheck) error {
+	method := hc.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	if !allowedMethods[method] {
+		return fmt.Errorf("unsupported method %s", hc.Method)
+	}
+
+	for k, v := range hc.Headers {
+		if !validHeaderName(k) {
+			return fmt.Errorf("invalid header name %q", k)
+		}
+		if !httpguts.ValidHeaderFieldValue(v) {
+			return fmt.Errorf("invalid value for header %q", k)
+		}
+	}
+
+	if hc.Body != "" && (method == http.MethodGet || method == http.MethodHead) {
+		return fmt.Errorf("request body not allowed with method %s", method)
+	}
+
+	if _, err := ParseStatusCodes(hc.ExpectedStatus); err != nil {
+		return err
+	}
+
+	if hc.MaxBodyBytes < 0 {
+		return fmt.Errorf("max_body_bytes cannot be negative")
+	}
+
+	if hc.CertWarnDays < 0 || hc.CertFailDays < 0 {
+		return fmt.Errorf("cert_warn_days and cert_fail_days cannot be negative")
+	}
+
+	if err := ValidateAssertions(hc.Assertions); err != nil {
+		return err
+	}
+	return validateOptions(hc, "http", "method", "headers", "body", "expected_status", "assertions", "max_body_bytes",
+		"cert_warn_days", "cert_fail_days")
+}
+
+// Check sends the request and evaluates the response status, body and certificate chain
+func (httpChecker) Check(ctx context.Context, hc *models.HealthCheck) error {
+	t := time.Now()
+
+	method := hc.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	request, err := http.NewRequest(method, hc.Endpoint, strings.NewReader(hc.Body))
+	if err != nil {
+		return err
+	}
+	request = request.WithContext(ctx)
+
+	for k, v := range hc.Headers {
+		// the Host header is ignored by the http client, it has to be set on the request itself
+		if http.CanonicalHeaderKey(k) == "Host" {
+			request.Host = v
+			continue
+		}
+		request.Header.Set(k, v)
+	}
+
+	resp, err := defaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	maxBody := hc.MaxBodyBytes
+	if maxBody <= 0 {
+		maxBody = defaultMaxBodyBytes
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxBody))
+	if err != nil {
+		return err
+	}
+
+	hc.Code = int32(resp.StatusCode)
+	hc.Status = resp.Status
+	hc.TLS = tlsInfo(resp.TLS, t)
+	hc.Healthy = true
+
+	if err := checkResponse(hc, resp.StatusCode, body); err != nil {
+		hc.Healthy = false
+		hc.Error = err.Error()
+	}
+	checkCertExpiry(hc)
+	return nil
+}
+
+// checkResponse validates the status code and body of a response against the healthcheck's expectations
+func checkResponse(hc *models.HealthCheck, statusCode int, body []byte) error {
+	expected, err := ParseStatusCodes(hc.ExpectedStatus)
+	if err != nil {
+		return err
+	}
+	if !expected.Match(statusCode) {
+		return fmt.Errorf("unexpected status code %d", statusCode)
+	}
+
+	return checkAssertions(hc.Assertions, body)
+}
+
+// validHeaderName reports whether name is a valid http header field name (RFC 7230 token)
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("!#$%&'*+-.^_`|~", r)) {
+			return false
+		}
+	}
+	return true
+}
@@ -5,38 +5,63 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/dnguy078/healthcheck/pkg/maintenance"
 	"github.com/dnguy078/healthcheck/pkg/models"
+	"github.com/dnguy078/healthcheck/pkg/notify"
 )
 
 var (
-	maxWorkers         = 10
-	defaultClient      = http.DefaultClient
-	defaultHTTPTimeout = 1 * time.Second
+	maxWorkers = 10
+	// defaultClient does not follow redirects, a 3xx response is checked against the expected status as is
+	defaultClient = &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	defaultTimeout = 1 * time.Second
+	// defaultMaxBodyBytes caps how much of a response body is read when a healthcheck does not set one
+	defaultMaxBodyBytes int64 = 1 << 20
 )
 
-// Reporter schedules the healthcheck based on checkFrequency
+// Reporter schedules each healthcheck based on its own interval, falling back to checkFrequency
 type Reporter struct {
 	tickRate time.Duration
 	quit     chan bool
 	results  chan *models.HealthCheck
 	storage  hcStorage
 	jobQueue chan *models.HealthCheck
+	schedule *scheduler
+	// scheduledAs is the endpoint and interval each healthcheck was scheduled with
+	scheduledAs map[string]string
+
+	failureThreshold int
+	successThreshold int
+	notifier         eventSender
+}
+
+type eventSender interface {
+	Send(e notify.Event)
 }
 
 type hcStorage interface {
 	List() models.HealthChecks
-	Create(input *models.HealthCheck) error
+	Get(id string) (*models.HealthCheck, error)
+	Record(result *models.HealthCheck) error
+	ListMaintenance() []*models.MaintenanceWindow
 }
 
 // NewReporter returns a reporter
 func NewReporter(frequencyRate time.Duration, db hcStorage) (*Reporter, error) {
 	results := make(chan *models.HealthCheck)
 	r := &Reporter{
-		tickRate: frequencyRate,
-		quit:     make(chan bool),
-		results:  results,
-		jobQueue: make(chan *models.HealthCheck),
-		storage:  db,
+		tickRate:    frequencyRate,
+		quit:        make(chan bool),
+		results:     results,
+		jobQueue:    make(chan *models.HealthCheck),
+		storage:     db,
+		schedule:    newScheduler(),
+		scheduledAs: make(map[string]string),
+
+		failureThreshold: defaultFailureThreshold,
+		successThreshold: defaultSuccessThreshold,
 	}
 
 	for i := 0; i < maxWorkers; i++ {
@@ -53,28 +78,205 @@ func NewReporter(frequencyRate time.Duration, db hcStorage) (*Reporter, error) {
 	return r, nil
 }
 
-// Report ticks based upon check frequency and routes healthchecks to be performed to the dispatcher
+// SetThresholds sets how many consecutive failures mark a healthcheck DOWN and how many consecutive
+// successes mark it UP, for healthchecks that do not set their own
+func (r *Reporter) SetThresholds(failures, successes int) {
+	r.failureThreshold = failures
+	r.successThreshold = successes
+}
+
+// SetNotifier sets where state change events are sent
+func (r *Reporter) SetNotifier(n eventSender) {
+	r.notifier = n
+}
+
+// Report dispatches healthchecks to the workers as they come due. Storage is re-read at least every
+// checkFrequency to pick up created and deleted healthchecks
 func (r *Reporter) Report() {
-	ticker := time.NewTicker(r.tickRate)
+	go r.handleResults()
 	go func() {
+		timer := time.NewTimer(0)
+		defer timer.Stop()
 		for {
 			select {
-			case <-ticker.C:
-				list := r.storage.List()
-
-				for _, hc := range list {
-					r.jobQueue <- hc
+			case now := <-timer.C:
+				if !r.dispatch(now) {
+					return
 				}
-			case res := <-r.results:
-				go r.storage.Create(res)
+				timer.Reset(r.nextWake(time.Now()))
 			case <-r.quit:
-				ticker.Stop()
 				return
 			}
 		}
 	}()
 }
 
+// dispatch syncs the schedule with storage and queues every healthcheck that is due, healthchecks in a
+// skipping maintenance window are rescheduled without running. Returns false if the reporter was stopped
+// while queueing
+func (r *Reporter) dispatch(now time.Time) bool {
+	checks := make(map[string]*models.HealthCheck)
+	for _, hc := range r.storage.List() {
+		checks[hc.ID] = hc
+		// new healthchecks, and updated ones whose endpoint or interval changed, run right away
+		as := hc.Endpoint + " every " + r.interval(hc).String()
+		if !r.schedule.Scheduled(hc.ID) || r.scheduledAs[hc.ID] != as {
+			r.schedule.Schedule(hc.ID, now)
+			r.scheduledAs[hc.ID] = as
+		}
+	}
+	for _, id := range r.schedule.IDs() {
+		if _, ok := checks[id]; !ok {
+			r.schedule.Remove(id)
+			delete(r.scheduledAs, id)
+		}
+	}
+
+	windows := r.storage.ListMaintenance()
+	for _, id := range r.schedule.PopDue(now) {
+		hc := checks[id]
+		r.schedule.Schedule(id, now.Add(r.interval(hc)))
+		if maintenance.Mode(windows, hc, now) == models.MaintenanceSkip {
+			continue
+		}
+
+		// composite checks are not run, they are evaluated with the results
+		queue := r.jobQueue
+		if isComposite(hc.Endpoint) {
+			queue = r.results
+		}
+		select {
+		case queue <- hc.Copy():
+		case <-r.quit:
+			return false
+		}
+	}
+	return true
+}
+
+// nextWake returns how long to sleep until the next healthcheck is due, capped at the tick rate so
+// storage is resynced
+func (r *Reporter) nextWake(now time.Time) time.Duration {
+	wake := r.tickRate
+	if next, ok := r.schedule.Next(); ok && next.Sub(now) < wake {
+		wake = next.Sub(now)
+	}
+	if wake < 0 {
+		wake = 0
+	}
+	return wake
+}
+
+// interval returns how often a healthcheck runs, defaulting to the tick rate
+func (r *Reporter) interval(hc *models.HealthCheck) time.Duration {
+	if interval, err := time.ParseDuration(hc.Interval); err == nil && interval > 0 {
+		return interval
+	}
+	return r.tickRate
+}
+
+// handleResults processes results as workers finish running healthchecks
+func (r *Reporter) handleResults() {
+	for {
+		select {
+		case res := <-r.results:
+			r.process(res)
+		case <-r.quit:
+			return
+		}
+	}
+}
+
+// process applies a result to the stored healthcheck's state and records both. Composite checks are
+// evaluated from their members instead
+func (r *Reporter) process(res *models.HealthCheck) {
+	hc, err := r.storage.Get(res.ID)
+	if err != nil {
+		log.Printf("unable to record healthcheck result, err: %s", err)
+		return
+	}
+
+	now := time.Now()
+	if isComposite(hc.Endpoint) {
+		evaluateComposite(hc, r.storage.List(), now)
+	} else {
+		hc.Result = res.Result
+	}
+	r.apply(hc, now, map[string]bool{hc.ID: true})
+}
+
+// apply updates a healthcheck's state from its latest result and records both, state changes are sent to
+// the notifier and re-evaluate the composite checks it is a member of. During maintenance the result is
+// recorded but the state is left unchanged. visited holds the checks already applied, so composites are
+// evaluated at most once per result
+func (r *Reporter) apply(hc *models.HealthCheck, now time.Time, visited map[string]bool) {
+	from := hc.State
+	changed := false
+	// a skipping window may have started while the check was running. The flag is set on every result as
+	// jobs are copies of the stored check, so it would otherwise outlive the window
+	hc.Maintenance = maintenance.Mode(r.storage.ListMaintenance(), hc, now) != ""
+	if !hc.Maintenance {
+		failureThreshold, successThreshold := r.failureThreshold, r.successThreshold
+		// a composite's members have already met their thresholds
+		if isComposite(hc.Endpoint) {
+			failureThreshold, successThreshold = 1, 1
+		}
+		changed = evaluateState(hc, now, failureThreshold, successThreshold, r.parentDown(hc))
+	}
+
+	if err := r.storage.Record(hc); err != nil {
+		log.Printf("unable to record healthcheck result, err: %s", err)
+		return
+	}
+
+	if !changed {
+		return
+	}
+	log.Printf("healthcheck %s (%s) changed state %s -> %s", hc.ID, hc.Endpoint, from, hc.State)
+	if r.notifier != nil && !suppressed(from, hc.State) {
+		r.notifier.Send(notify.NewEvent(hc, from))
+	}
+	r.updateComposites(hc, now, visited)
+}
+
+// updateComposites re-evaluates the composite checks a healthcheck is a member of
+func (r *Reporter) updateComposites(member *models.HealthCheck, now time.Time, visited map[string]bool) {
+	for _, c := range r.storage.List() {
+		if !isComposite(c.Endpoint) || visited[c.ID] || !isMember(c, member) {
+			continue
+		}
+		visited[c.ID] = true
+
+		composite, err := r.storage.Get(c.ID)
+		if err != nil {
+			continue
+		}
+		evaluateComposite(composite, r.storage.List(), now)
+		r.apply(composite, now, visited)
+	}
+}
+
+// parentDown returns whether any of a healthcheck's parents is DOWN or UNREACHABLE, parents that no longer
+// exist are ignored
+func (r *Reporter) parentDown(hc *models.HealthCheck) bool {
+	for _, id := range hc.Parents {
+		parent, err := r.storage.Get(id)
+		if err != nil {
+			continue
+		}
+		if parent.State == models.StateDown || parent.State == models.StateUnreachable {
+			return true
+		}
+	}
+	return false
+}
+
+// suppressed returns whether a state change is not notified. Changes to UNREACHABLE are covered by the
+// parent's notification, and so are recoveries from it unless the check is still DOWN once its parent is back
+func suppressed(from, to string) bool {
+	return to == models.StateUnreachable || (from == models.StateUnreachable && to != models.StateDown)
+}
+
 // Stop the reporter
 func (r *Reporter) Stop() {
 	log.Print("Stopping healthcheck reporter")
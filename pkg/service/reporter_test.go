@@ -1,9 +1,15 @@
 package service
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/dnguy078/healthcheck/pkg/models"
+	"github.com/dnguy078/healthcheck/pkg/notify"
 	"github.com/dnguy078/healthcheck/pkg/storage/mocks"
 )
 
@@ -37,3 +43,251 @@ func TestNewReporter(t *testing.T) {
 		})
 	}
 }
+
+func TestReporter_Intervals(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer s.Close()
+
+	db := &mocks.FakeCollection{
+		ListResp: models.HealthChecks{
+			&models.HealthCheck{ID: "fast", Endpoint: s.URL + "/fast", Interval: "20ms"},
+			&models.HealthCheck{ID: "slow", Endpoint: s.URL + "/slow", Interval: "1h"},
+			&models.HealthCheck{ID: "default", Endpoint: s.URL + "/default"},
+		},
+	}
+
+	r, err := NewReporter(1*time.Hour, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Report()
+	time.Sleep(300 * time.Millisecond)
+	r.Stop()
+
+	if got := db.RecordedCount("fast"); got < 5 {
+		t.Errorf("expected fast check to run every 20ms, ran %d times", got)
+	}
+	if got := db.RecordedCount("slow"); got != 1 {
+		t.Errorf("expected slow check to run once, ran %d times", got)
+	}
+	if got := db.RecordedCount("default"); got != 1 {
+		t.Errorf("expected default check to run once, ran %d times", got)
+	}
+}
+
+func TestReporter_Reschedule(t *testing.T) {
+	var mu sync.Mutex
+	paths := map[string]int{}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths[r.URL.Path]++
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer s.Close()
+
+	db := &mocks.FakeCollection{
+		ListResp: models.HealthChecks{
+			&models.HealthCheck{ID: "interval", Endpoint: s.URL + "/interval", Interval: "1h"},
+			&models.HealthCheck{ID: "endpoint", Endpoint: s.URL + "/endpoint", Interval: "1h"},
+		},
+	}
+
+	r, err := NewReporter(20*time.Millisecond, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Report()
+	time.Sleep(100 * time.Millisecond)
+	db.Update(&models.HealthCheck{ID: "interval", Endpoint: s.URL + "/interval", Interval: "20ms"})
+	db.Update(&models.HealthCheck{ID: "endpoint", Endpoint: s.URL + "/moved", Interval: "1h"})
+	time.Sleep(300 * time.Millisecond)
+	r.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := paths["/interval"]; got < 5 {
+		t.Errorf("expected the shortened interval to be rescheduled, ran %d times", got)
+	}
+	if paths["/endpoint"] != 1 || paths["/moved"] != 1 {
+		t.Errorf("expected the moved endpoint to run right away, got %v", paths)
+	}
+}
+
+func TestReporter_Process(t *testing.T) {
+	db := &mocks.FakeCollection{
+		ListResp: models.HealthChecks{
+			&models.HealthCheck{ID: "a", Endpoint: "https://www.blizzard.com", FailureThreshold: 2, Tracking: models.Tracking{State: models.StateUp}},
+		},
+	}
+	r, err := NewReporter(time.Hour, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Stop()
+
+	sender := &fakeSender{}
+	r.SetNotifier(sender)
+
+	for i, want := range []string{models.StateUp, models.StateDown} {
+		r.process(&models.HealthCheck{ID: "a", Result: models.Result{Healthy: false}})
+
+		hc, _ := db.Get("a")
+		if hc.State != want || hc.ConsecutiveFailures != i+1 {
+			t.Errorf("failure %d: got state %s with %d failures, expected %s", i+1, hc.State, hc.ConsecutiveFailures, want)
+		}
+	}
+
+	if len(sender.events) != 1 || sender.events[0].OldState != models.StateUp || sender.events[0].NewState != models.StateDown {
+		t.Errorf("expected a single UP -> DOWN event, got %+v", sender.events)
+	}
+}
+
+func TestReporter_Dependencies(t *testing.T) {
+	db := &mocks.FakeCollection{
+		ListResp: models.HealthChecks{
+			&models.HealthCheck{ID: "lb", Endpoint: "https://lb.example.com", FailureThreshold: 1, Tracking: models.Tracking{State: models.StateUp}},
+			&models.HealthCheck{ID: "app", Endpoint: "https://app.example.com", Parents: []string{"lb"}, FailureThreshold: 1, Tracking: models.Tracking{State: models.StateUp}},
+		},
+	}
+	r, err := NewReporter(time.Hour, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Stop()
+	sender := &fakeSender{}
+	r.SetNotifier(sender)
+
+	steps := []struct {
+		id        string
+		healthy   bool
+		wantState string
+	}{
+		{id: "lb", wantState: models.StateDown},
+		{id: "app", wantState: models.StateUnreachable},
+		{id: "lb", healthy: true, wantState: models.StateUp},
+		// still failing once the parent is back is DOWN
+		{id: "app", wantState: models.StateDown},
+		{id: "app", healthy: true, wantState: models.StateUp},
+		// already DOWN when the parent fails stays DOWN so the recovery is notified
+		{id: "app", wantState: models.StateDown},
+		{id: "lb", wantState: models.StateDown},
+		{id: "app", wantState: models.StateDown},
+		{id: "app", healthy: true, wantState: models.StateUp},
+		{id: "lb", healthy: true, wantState: models.StateUp},
+	}
+	for i, step := range steps {
+		r.process(&models.HealthCheck{ID: step.id, Result: models.Result{Healthy: step.healthy}})
+		if hc, _ := db.Get(step.id); hc.State != step.wantState {
+			t.Errorf("step %d: got %s state %s, expected %s", i, step.id, hc.State, step.wantState)
+		}
+	}
+
+	var got []string
+	for _, e := range sender.events {
+		got = append(got, e.Check.ID+" "+e.OldState+" -> "+e.NewState)
+	}
+	want := []string{
+		"lb UP -> DOWN", "lb DOWN -> UP", "app UNREACHABLE -> DOWN", "app DOWN -> UP",
+		"app UP -> DOWN", "lb UP -> DOWN", "app DOWN -> UP", "lb DOWN -> UP",
+	}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("got events %v, expected %v", got, want)
+	}
+}
+
+func TestReporter_Maintenance(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer s.Close()
+
+	now := time.Now()
+	db := &mocks.FakeCollection{
+		ListResp: models.HealthChecks{
+			&models.HealthCheck{ID: "skipped", Endpoint: s.URL + "/skipped", Labels: map[string]string{"role": "db"}, Tracking: models.Tracking{State: models.StateUp}},
+			&models.HealthCheck{ID: "suppressed", Endpoint: s.URL + "/suppressed", FailureThreshold: 1, Tracking: models.Tracking{State: models.StateUp}},
+		},
+		Maintenance: map[string]*models.MaintenanceWindow{
+			"skip":     {ID: "skip", Selector: "role=db", Mode: models.MaintenanceSkip, Start: now.Add(-time.Hour).Unix(), End: now.Add(time.Hour).Unix()},
+			"suppress": {ID: "suppress", CheckIDs: []string{"suppressed"}, Mode: models.MaintenanceSuppress, Start: now.Add(-time.Hour).Unix(), End: now.Add(time.Hour).Unix()},
+		},
+	}
+
+	r, err := NewReporter(time.Hour, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := &fakeSender{}
+	r.SetNotifier(sender)
+	r.Report()
+	time.Sleep(200 * time.Millisecond)
+	r.Stop()
+
+	if got := db.RecordedCount("skipped"); got != 0 {
+		t.Errorf("expected skipped check not to run, ran %d times", got)
+	}
+	if got := db.RecordedCount("suppressed"); got != 1 {
+		t.Fatalf("expected suppressed check to run once, ran %d times", got)
+	}
+	hc, _ := db.Get("suppressed")
+	if hc.State != models.StateUp || !hc.Maintenance || hc.Healthy {
+		t.Errorf("expected the failed result to be recorded without a transition, got %+v", hc)
+	}
+	if len(sender.events) != 0 {
+		t.Errorf("expected no notifications, got %+v", sender.events)
+	}
+}
+
+func TestReporter_MaintenanceEnds(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer s.Close()
+
+	now := time.Now()
+	db := &mocks.FakeCollection{
+		ListResp: models.HealthChecks{
+			&models.HealthCheck{ID: "a", Endpoint: s.URL, FailureThreshold: 1, Tracking: models.Tracking{State: models.StateUp}},
+		},
+		Maintenance: map[string]*models.MaintenanceWindow{
+			"suppress": {ID: "suppress", CheckIDs: []string{"a"}, Mode: models.MaintenanceSuppress, Start: now.Add(-time.Hour).Unix(), End: now.Add(time.Hour).Unix()},
+		},
+	}
+	r, err := NewReporter(time.Hour, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Stop()
+
+	// jobs are copies of the stored check, like the ones dispatched to the workers
+	run := func() *models.HealthCheck {
+		hc, _ := db.Get("a")
+		r.process(Run(hc, time.Second))
+		hc, _ = db.Get("a")
+		return hc
+	}
+
+	if hc := run(); !hc.Maintenance || hc.State != models.StateUp {
+		t.Fatalf("expected the result to be recorded as maintenance, got %+v", hc)
+	}
+	if err := db.DeleteMaintenance("suppress"); err != nil {
+		t.Fatal(err)
+	}
+	if hc := run(); hc.Maintenance || hc.State != models.StateDown {
+		t.Errorf("expected the result after the window to be applied without the maintenance flag, got %+v", hc)
+	}
+	if last := db.Recorded[len(db.Recorded)-1]; last.Maintenance {
+		t.Error("expected the recorded result not to be flagged as maintenance")
+	}
+}
+
+type fakeSender struct {
+	events []notify.Event
+}
+
+func (fs *fakeSender) Send(e notify.Event) {
+	fs.events = append(fs.events, e)
+}
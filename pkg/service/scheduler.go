@@ -0,0 +1,107 @@
+package service
+
+import (
+	"container/heap"
+	"time"
+)
+
+// scheduler is a min-heap of healthcheck ids ordered by when each check is next due
+type scheduler struct {
+	entries entryHeap
+	index   map[string]*entry
+}
+
+type entry struct {
+	id    string
+	due   time.Time
+	index int
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{
+		index: make(map[string]*entry),
+	}
+}
+
+// Schedule sets when a healthcheck is next due, adding it if it is not already scheduled
+func (s *scheduler) Schedule(id string, due time.Time) {
+	if e, ok := s.index[id]; ok {
+		e.due = due
+		heap.Fix(&s.entries, e.index)
+		return
+	}
+
+	e := &entry{id: id, due: due}
+	heap.Push(&s.entries, e)
+	s.index[id] = e
+}
+
+// Remove unschedules a healthcheck
+func (s *scheduler) Remove(id string) {
+	if e, ok := s.index[id]; ok {
+		heap.Remove(&s.entries, e.index)
+		delete(s.index, id)
+	}
+}
+
+// Scheduled reports whether a healthcheck is scheduled
+func (s *scheduler) Scheduled(id string) bool {
+	_, ok := s.index[id]
+	return ok
+}
+
+// IDs returns the ids of every scheduled healthcheck
+func (s *scheduler) IDs() []string {
+	ids := make([]string, 0, len(s.index))
+	for id := range s.index {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Next returns when the earliest healthcheck is due, false if nothing is scheduled
+func (s *scheduler) Next() (time.Time, bool) {
+	if len(s.entries) == 0 {
+		return time.Time{}, false
+	}
+	return s.entries[0].due, true
+}
+
+// PopDue removes and returns every healthcheck due at or before now, earliest first
+func (s *scheduler) PopDue(now time.Time) []string {
+	var due []string
+	for len(s.entries) > 0 && !s.entries[0].due.After(now) {
+		e := heap.Pop(&s.entries).(*entry)
+		delete(s.index, e.id)
+		due = append(due, e.id)
+	}
+	return due
+}
+
+// entryHeap implements heap.Interface
+type entryHeap []*entry
+
+func (h entryHeap) Len() int {
+	return len(h)
+}
+func (h entryHeap) Less(i, j int) bool {
+	return h[i].due.Before(h[j].due)
+}
+func (h entryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *entryHeap) Push(x interface{}) {
+	e := x.(*entry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+func (h *entryHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return e
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	now := time.Now()
+	s := newScheduler()
+	s.Schedule("c", now.Add(3*time.Second))
+	s.Schedule("a", now.Add(1*time.Second))
+	s.Schedule("b", now.Add(2*time.Second))
+	s.Schedule("d", now.Add(4*time.Second))
+
+	// reschedule and remove entries already in the heap
+	s.Schedule("d", now)
+	s.Remove("b")
+
+	next, ok := s.Next()
+	if !ok || !next.Equal(now) {
+		t.Errorf("expected next to be due now, got %s", next)
+	}
+
+	if got := s.PopDue(now.Add(1 * time.Second)); !reflect.DeepEqual(got, []string{"d", "a"}) {
+		t.Errorf("expected d, a to be due, got %v", got)
+	}
+	if s.Scheduled("a") || s.Scheduled("b") || !s.Scheduled("c") {
+		t.Error("expected only c to still be scheduled")
+	}
+	if got := s.PopDue(now.Add(2 * time.Second)); len(got) != 0 {
+		t.Errorf("expected nothing to be due, got %v", got)
+	}
+	if got := s.PopDue(now.Add(time.Minute)); !reflect.DeepEqual(got, []string{"c"}) {
+		t.Errorf("expected c to be due, got %v", got)
+	}
+	if _, ok := s.Next(); ok {
+		t.Error("expected the schedule to be empty")
+	}
+}
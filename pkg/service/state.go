@@ -0,0 +1,112 @@
+package service
+
+import (
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+var (
+	defaultFailureThreshold = 3
+	defaultSuccessThreshold = 1
+
+	// flap detection follows nagios, the percentage of state changes over the last flapWindow results is
+	// weighted towards recent results. A check starts flapping at flapHighThreshold and stops once it
+	// drops below flapLowThreshold
+	flapWindow        = 21
+	flapHighThreshold = 50.0
+	flapLowThreshold  = 25.0
+)
+
+// evaluateState applies the healthcheck's latest result to its consecutive counters, flap score and state,
+// returning true if the state changed. A check only goes DOWN after failureThreshold consecutive failures
+// and only comes back UP after successThreshold consecutive successes, once up a warning flips it between
+// UP and DEGRADED straight away. While a check is flapping its state is held at FLAPPING. A check that would
+// go DOWN while parentDown is set is UNREACHABLE instead, one already DOWN stays DOWN so its recovery is
+// still notified
+func evaluateState(hc *models.HealthCheck, now time.Time, failureThreshold, successThreshold int, parentDown bool) bool {
+	if hc.FailureThreshold > 0 {
+		failureThreshold = hc.FailureThreshold
+	}
+	if hc.SuccessThreshold > 0 {
+		successThreshold = hc.SuccessThreshold
+	}
+
+	if hc.Healthy {
+		hc.ConsecutiveSuccesses++
+		hc.ConsecutiveFailures = 0
+	} else {
+		hc.ConsecutiveFailures++
+		hc.ConsecutiveSuccesses = 0
+	}
+	updateFlapping(hc)
+
+	state := hc.State
+	if state == "" {
+		state = models.StateUnknown
+	}
+
+	next := state
+	switch {
+	case hc.Flapping:
+		next = models.StateFlapping
+	case !hc.Healthy && hc.ConsecutiveFailures >= failureThreshold:
+		next = models.StateDown
+		if parentDown && state != models.StateDown {
+			next = models.StateUnreachable
+		}
+	case hc.Healthy && (isUp(state) || hc.ConsecutiveSuccesses >= successThreshold):
+		next = models.StateUp
+		if hc.Warning != "" {
+			next = models.StateDegraded
+		}
+	case state == models.StateFlapping:
+		// stopped flapping without enough consecutive results to be UP or DOWN
+		next = models.StateUnknown
+	}
+
+	hc.State = next
+	if next == state {
+		return false
+	}
+	hc.LastTransition = now.Unix()
+	return true
+}
+
+func isUp(state string) bool {
+	return state == models.StateUp || state == models.StateDegraded
+}
+
+// updateFlapping adds the latest result to the flap window and recomputes the flap score
+func updateFlapping(hc *models.HealthCheck) {
+	hc.Recent = append(hc.Recent, hc.Healthy)
+	if len(hc.Recent) > flapWindow {
+		hc.Recent = hc.Recent[len(hc.Recent)-flapWindow:]
+	}
+
+	hc.FlapScore = flapScore(hc.Recent)
+	switch {
+	case hc.FlapScore >= flapHighThreshold:
+		hc.Flapping = true
+	case hc.FlapScore < flapLowThreshold:
+		hc.Flapping = false
+	}
+}
+
+// flapScore returns the weighted percentage of state changes between consecutive results, changes are
+// weighted from 0.8 for the oldest to 1.2 for the newest
+func flapScore(recent []bool) float64 {
+	transitions := flapWindow - 1
+	if len(recent) < 2 {
+		return 0
+	}
+
+	var score float64
+	offset := flapWindow - len(recent)
+	for i := 1; i < len(recent); i++ {
+		if recent[i] != recent[i-1] {
+			score += 0.8 + 0.4*float64(offset+i-1)/float64(transitions-1)
+		}
+	}
+	return score / float64(transitions) * 100
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+func TestEvaluateState(t *testing.T) {
+	pass := models.Result{Healthy: true}
+	fail := models.Result{Healthy: false}
+	warn := models.Result{Healthy: true, Warning: "certificate expires in 3 days"}
+
+	tests := []struct {
+		name             string
+		hc               models.HealthCheck
+		parentDown       bool
+		results          []models.Result
+		wantStates       []string
+		wantTransitioned []bool
+	}{
+		{
+			name:             "unknown to up",
+			results:          []models.Result{pass},
+			wantStates:       []string{models.StateUp},
+			wantTransitioned: []bool{true},
+		},
+		{
+			name:             "down after consecutive failures",
+			hc:               models.HealthCheck{Tracking: models.Tracking{State: models.StateUp}},
+			results:          []models.Result{fail, fail, pass, fail, fail, fail},
+			wantStates:       []string{models.StateUp, models.StateUp, models.StateUp, models.StateUp, models.StateUp, models.StateDown},
+			wantTransitioned: []bool{false, false, false, false, false, true},
+		},
+		{
+			name:             "up after consecutive successes",
+			hc:               models.HealthCheck{SuccessThreshold: 2, Tracking: models.Tracking{State: models.StateDown}},
+			results:          []models.Result{pass, fail, pass, pass},
+			wantStates:       []string{models.StateDown, models.StateDown, models.StateDown, models.StateUp},
+			wantTransitioned: []bool{false, false, false, true},
+		},
+		{
+			name:             "per check failure threshold",
+			hc:               models.HealthCheck{FailureThreshold: 1, Tracking: models.Tracking{State: models.StateUp}},
+			results:          []models.Result{fail},
+			wantStates:       []string{models.StateDown},
+			wantTransitioned: []bool{true},
+		},
+		{
+			name:             "degraded on warning",
+			hc:               models.HealthCheck{Tracking: models.Tracking{State: models.StateUp}},
+			results:          []models.Result{warn, warn, pass},
+			wantStates:       []string{models.StateDegraded, models.StateDegraded, models.StateUp},
+			wantTransitioned: []bool{true, false, true},
+		},
+		{
+			name:             "unreachable while a parent is down",
+			hc:               models.HealthCheck{Tracking: models.Tracking{State: models.StateUp}},
+			parentDown:       true,
+			results:          []models.Result{fail, fail, fail, fail, pass},
+			wantStates:       []string{models.StateUp, models.StateUp, models.StateUnreachable, models.StateUnreachable, models.StateUp},
+			wantTransitioned: []bool{false, false, true, false, true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hc := tt.hc
+			now := time.Now()
+			for i, res := range tt.results {
+				hc.Result = res
+				changed := evaluateState(&hc, now, 3, 1, tt.parentDown)
+				if hc.State != tt.wantStates[i] || changed != tt.wantTransitioned[i] {
+					t.Errorf("result %d: got state %s changed %v, expected %s %v", i, hc.State, changed, tt.wantStates[i], tt.wantTransitioned[i])
+				}
+				if changed && hc.LastTransition != now.Unix() {
+					t.Errorf("result %d: expected last transition to be set", i)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateState_Flapping(t *testing.T) {
+	hc := &models.HealthCheck{FailureThreshold: 1, Tracking: models.Tracking{State: models.StateUp}}
+	now := time.Now()
+
+	// alternate between passing and failing until the check is flapping
+	transitions := 0
+	for i := 0; i < 20 && hc.State != models.StateFlapping; i++ {
+		hc.Result = models.Result{Healthy: i%2 == 1}
+		if evaluateState(hc, now, 3, 1, false) {
+			transitions++
+		}
+	}
+	if hc.State != models.StateFlapping || !hc.Flapping || hc.FlapScore < flapHighThreshold {
+		t.Fatalf("expected check to be flapping, got state %s score %v", hc.State, hc.FlapScore)
+	}
+
+	// state is held while flapping, no more transitions until it settles
+	for i := 0; i < 4; i++ {
+		hc.Result = models.Result{Healthy: i%2 == 1}
+		if evaluateState(hc, now, 3, 1, false) {
+			t.Errorf("expected no transitions while flapping, got %s", hc.State)
+		}
+	}
+
+	for i := 0; i < flapWindow && hc.Flapping; i++ {
+		hc.Result = models.Result{Healthy: true}
+		evaluateState(hc, now, 3, 1, false)
+	}
+	if hc.Flapping || hc.State != models.StateUp || hc.FlapScore >= flapLowThreshold {
+		t.Errorf("expected check to settle UP, got state %s score %v", hc.State, hc.FlapScore)
+	}
+	if len(hc.Recent) > flapWindow {
+		t.Errorf("expected at most %d recent results, got %d", flapWindow, len(hc.Recent))
+	}
+}
+
+func TestFlapScore(t *testing.T) {
+	alternating := make([]bool, flapWindow)
+	for i := range alternating {
+		alternating[i] = i%2 == 0
+	}
+	steady := make([]bool, flapWindow)
+
+	tests := []struct {
+		name   string
+		recent []bool
+		want   float64
+	}{
+		{name: "empty", recent: nil, want: 0},
+		{name: "steady", recent: steady, want: 0},
+		{name: "alternating", recent: alternating, want: 100},
+		{name: "newest change weighs more", recent: append(append([]bool(nil), steady[1:]...), true), want: 1.2 / 20 * 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := flapScore(tt.recent); got < tt.want-0.001 || got > tt.want+0.001 {
+				t.Errorf("flapScore() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultExpectedStatus is used when a healthcheck does not declare the status codes it accepts
+const DefaultExpectedStatus = "200-399"
+
+// StatusCodes is a set of accepted http status code ranges
+type StatusCodes []statusRange
+
+type statusRange struct {
+	min, max int
+}
+
+// ParseStatusCodes parses a comma separated list of status codes and ranges, ie) 200-299,301
+func ParseStatusCodes(spec string) (StatusCodes, error) {
+	if strings.TrimSpace(spec) == "" {
+		spec = DefaultExpectedStatus
+	}
+
+	var codes StatusCodes
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		bounds := strings.SplitN(part, "-", 2)
+
+		min, err := parseStatusCode(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		max := min
+		if len(bounds) == 2 {
+			if max, err = parseStatusCode(bounds[1]); err != nil {
+				return nil, err
+			}
+		}
+		if min > max {
+			return nil, fmt.Errorf("invalid status code range %q", part)
+		}
+
+		codes = append(codes, statusRange{min: min, max: max})
+	}
+
+	return codes, nil
+}
+
+func parseStatusCode(s string) (int, error) {
+	code, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || code < 100 || code > 599 {
+		return 0, fmt.Errorf("invalid status code %q", s)
+	}
+	return code, nil
+}
+
+// Match reports whether code is one of the accepted status codes
+func (sc StatusCodes) Match(code int) bool {
+	for _, r := range sc {
+		if code >= r.min && code <= r.max {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package service
+
+import "testing"
+
+func TestParseStatusCodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		match   []int
+		noMatch []int
+		wantErr bool
+	}{
+		{
+			name:    "default",
+			spec:    "",
+			match:   []int{200, 301, 399},
+			noMatch: []int{404, 500},
+		},
+		{
+			name:    "ranges and codes",
+			spec:    "200-299, 301",
+			match:   []int{200, 204, 299, 301},
+			noMatch: []int{302, 500},
+		},
+		{
+			name:    "invalid code",
+			spec:    "abc",
+			wantErr: true,
+		},
+		{
+			name:    "out of bounds",
+			spec:    "200-999",
+			wantErr: true,
+		},
+		{
+			name:    "inverted range",
+			spec:    "299-200",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseStatusCodes(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseStatusCodes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			for _, code := range tt.match {
+				if !got.Match(code) {
+					t.Errorf("expected %d to match %q", code, tt.spec)
+				}
+			}
+			for _, code := range tt.noMatch {
+				if got.Match(code) {
+					t.Errorf("expected %d not to match %q", code, tt.spec)
+				}
+			}
+		})
+	}
+}
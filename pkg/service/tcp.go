@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+// tcpChecker dials tcp://host:port endpoints, optionally sending a payload and expecting a response prefix
+type tcpChecker struct{}
+
+// Validate requires a host and port and rejects options the scheme does not use
+func (tcpChecker) Validate(hc *models.HealthCheck) error {
+	if _, err := tcpAddress(hc.Endpoint); err != nil {
+		return err
+	}
+
+	return validateOptions(hc, "tcp", "send", "expect")
+}
+
+// Check dials the endpoint and records the connect latency
+func (tcpChecker) Check(ctx context.Context, hc *models.HealthCheck) error {
+	addr, err := tcpAddress(hc.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	t := time.Now()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	hc.ConnectDuration = time.Since(t).String()
+	hc.Status = "Connected"
+	hc.Healthy = true
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if hc.Send != "" {
+		if _, err := io.WriteString(conn, hc.Send); err != nil {
+			return err
+		}
+	}
+
+	if hc.Expect != "" {
+		buf := make([]byte, len(hc.Expect))
+		n, err := io.ReadFull(conn, buf)
+		if err != nil && n == 0 {
+			return err
+		}
+		if string(buf[:n]) != hc.Expect {
+			hc.Healthy = false
+			hc.Error = fmt.Sprintf("unexpected response %q, expected prefix %q", buf[:n], hc.Expect)
+		}
+	}
+
+	return nil
+}
+
+// tcpAddress extracts host:port from a tcp://host:port endpoint
+func tcpAddress(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	if u.Hostname() == "" || u.Port() == "" {
+		return "", fmt.Errorf("tcp endpoint must be in the form tcp://host:port")
+	}
+	return u.Host, nil
+}
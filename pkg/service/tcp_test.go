@@ -0,0 +1,129 @@
+package service
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+func TestRun_TCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	// replies with PONG to any line it reads
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				if _, err := bufio.NewReader(conn).ReadString('\n'); err == nil {
+					conn.Write([]byte("+PONG\r\n"))
+				}
+			}(conn)
+		}
+	}()
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := closed.Addr().String()
+	closed.Close()
+
+	tests := []struct {
+		name        string
+		endpoint    string
+		send        string
+		expect      string
+		wantHealthy bool
+	}{
+		{
+			name:        "connect",
+			endpoint:    "tcp://" + l.Addr().String(),
+			wantHealthy: true,
+		},
+		{
+			name:        "send and expect",
+			endpoint:    "tcp://" + l.Addr().String(),
+			send:        "PING\r\n",
+			expect:      "+PONG",
+			wantHealthy: true,
+		},
+		{
+			name:        "unexpected response",
+			endpoint:    "tcp://" + l.Addr().String(),
+			send:        "PING\r\n",
+			expect:      "-ERR",
+			wantHealthy: false,
+		},
+		{
+			name:        "connection refused",
+			endpoint:    "tcp://" + closedAddr,
+			wantHealthy: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hc := &models.HealthCheck{
+				Endpoint: tt.endpoint,
+				Send:     tt.send,
+				Expect:   tt.expect,
+			}
+
+			got := Run(hc, 1*time.Second)
+			if got.Healthy != tt.wantHealthy {
+				t.Errorf("got healthy %v, expected %v, err: %s", got.Healthy, tt.wantHealthy, got.Error)
+			}
+			if got.Healthy && got.ConnectDuration == "" {
+				t.Error("expected connect latency to be recorded")
+			}
+		})
+	}
+}
+
+func TestRun_TCPFailureClearsPreviousResult(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := closed.Addr().String()
+	closed.Close()
+
+	hc := Run(&models.HealthCheck{Endpoint: "tcp://" + l.Addr().String()}, 1*time.Second)
+	if !hc.Healthy || hc.ConnectDuration == "" {
+		t.Fatalf("expected a healthy result with a connect latency, got %+v", hc.Result)
+	}
+
+	hc.Endpoint = "tcp://" + closedAddr
+	got := Run(hc, 1*time.Second)
+	if got.Healthy {
+		t.Fatal("expected the refused connection to be unhealthy")
+	}
+	if got.ConnectDuration != "" {
+		t.Errorf("expected no connect latency on a failed connection, got %s", got.ConnectDuration)
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+// tlsInfo summarizes the peer certificate chain of a tls connection, returns nil if no certificates
+// were presented
+func tlsInfo(state *tls.ConnectionState, now time.Time) *models.TLSInfo {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+
+	leaf := state.PeerCertificates[0]
+	expiry := leaf.NotAfter
+	for _, cert := range state.PeerCertificates[1:] {
+		if cert.NotAfter.Before(expiry) {
+			expiry = cert.NotAfter
+		}
+	}
+
+	sans := append([]string(nil), leaf.DNSNames...)
+	for _, ip := range leaf.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	return &models.TLSInfo{
+		Expiry:   expiry.Unix(),
+		DaysLeft: int(expiry.Sub(now).Hours() / 24),
+		Issuer:   leaf.Issuer.String(),
+		SANs:     sans,
+	}
+}
+
+// checkCertExpiry fails or warns the healthcheck when its certificate chain is within the configured
+// number of days of expiry
+func checkCertExpiry(hc *models.HealthCheck) {
+	if hc.TLS == nil {
+		return
+	}
+
+	msg := fmt.Sprintf("certificate expires in %d days", hc.TLS.DaysLeft)
+	if hc.TLS.DaysLeft < 0 {
+		msg = fmt.Sprintf("certificate expired %d days ago", -hc.TLS.DaysLeft)
+	}
+
+	switch {
+	case hc.TLS.DaysLeft < 0 || (hc.CertFailDays > 0 && hc.TLS.DaysLeft <= hc.CertFailDays):
+		if hc.Healthy {
+			hc.Healthy = false
+			hc.Error = msg
+		}
+	case hc.CertWarnDays > 0 && hc.TLS.DaysLeft <= hc.CertWarnDays:
+		hc.Warning = msg
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+func TestRun_CertExpiry(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer s.Close()
+
+	client := defaultClient
+	defaultClient = s.Client()
+	defer func() { defaultClient = client }()
+
+	// the httptest certificate is valid for decades, use thresholds far enough out to trigger
+	tests := []struct {
+		name         string
+		warnDays     int
+		failDays     int
+		wantHealthy  bool
+		wantWarning  bool
+		wantErrorMsg bool
+	}{
+		{
+			name:        "healthy",
+			warnDays:    30,
+			failDays:    7,
+			wantHealthy: true,
+		},
+		{
+			name:        "warn",
+			warnDays:    1000000,
+			wantHealthy: true,
+			wantWarning: true,
+		},
+		{
+			name:         "fail",
+			failDays:     1000000,
+			wantHealthy:  false,
+			wantErrorMsg: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hc := &models.HealthCheck{
+				Endpoint:     s.URL,
+				CertWarnDays: tt.warnDays,
+				CertFailDays: tt.failDays,
+			}
+
+			got := Run(hc, 1*time.Second)
+			if got.TLS == nil {
+				t.Fatalf("expected tls info to be recorded, err: %s", got.Error)
+			}
+			if got.TLS.Expiry == 0 || got.TLS.Issuer == "" || len(got.TLS.SANs) == 0 {
+				t.Errorf("expected expiry, issuer and sans, got %+v", got.TLS)
+			}
+			if got.Healthy != tt.wantHealthy {
+				t.Errorf("got healthy %v, expected %v", got.Healthy, tt.wantHealthy)
+			}
+			if (got.Warning != "") != tt.wantWarning {
+				t.Errorf("got warning %q, expected warning %v", got.Warning, tt.wantWarning)
+			}
+			if (got.Error != "") != tt.wantErrorMsg {
+				t.Errorf("got error %q, expected error %v", got.Error, tt.wantErrorMsg)
+			}
+		})
+	}
+}
@@ -3,7 +3,6 @@ package service
 import (
 	"context"
 	"log"
-	"net/http"
 	"time"
 
 	"github.com/dnguy078/healthcheck/pkg/models"
@@ -25,49 +24,47 @@ func (w Worker) Start() {
 			if !ok {
 				return
 			}
-			w.results <- Run(job, defaultHTTPTimeout)
+			w.results <- Run(job, Timeout(job))
 		case <-w.quit:
 			return
 		}
 	}
 }
 
-// Run performs healthchecks
+// Run performs a healthcheck using the checker registered for its endpoint scheme
 func Run(hc *models.HealthCheck, timeout time.Duration) *models.HealthCheck {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
+	// each job is a copy of the stored check, start from an empty result so nothing from the previous run is kept
 	t := time.Now()
+	hc.Result = models.Result{Checked: t.Unix()}
 	defer timeRequest(t, hc)
 
-	request, err := http.NewRequest("GET", hc.Endpoint, nil)
+	c, err := checkerFor(hc.Endpoint)
 	if err != nil {
 		handleErr(hc, err)
 		return hc
 	}
-	request = request.WithContext(ctx)
 
-	resp, err := defaultClient.Do(request)
-	if err != nil {
+	if err := c.Check(ctx, hc); err != nil {
 		handleErr(hc, err)
 		return hc
 	}
-
-	hc.Code = int32(resp.StatusCode)
-	hc.Status = resp.Status
-	hc.Checked = t.Unix()
-	hc.Error = ""
+	checkLatency(hc, time.Since(t))
 	return hc
 }
 
 func timeRequest(t time.Time, hc *models.HealthCheck) {
-	hc.Duration = time.Since(t).String()
+	elapsed := time.Since(t)
+	hc.Duration = elapsed.String()
+	hc.DurationMs = float64(elapsed) / float64(time.Millisecond)
 }
 
 func handleErr(hc *models.HealthCheck, err error) {
 	hc.Status = "Error"
+	hc.Healthy = false
 	hc.Error = err.Error()
-	hc.Code = 0
 	mapErr := map[string]string{
 		"error":   err.Error(),
 		"message": "error processing healthcheck",
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -52,6 +53,140 @@ func TestRun(t *testing.T) {
 	}
 }
 
+func TestRun_RequestOptions(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer abc" || string(body) != `{"ping":true}` {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer s.Close()
+
+	hc := &models.HealthCheck{
+		Endpoint: s.URL,
+		Method:   http.MethodPost,
+		Headers:  map[string]string{"Authorization": "Bearer abc"},
+		Body:     `{"ping":true}`,
+	}
+
+	got := Run(hc, 1*time.Second)
+	if got.Code != http.StatusCreated {
+		t.Errorf("expected method, headers and body to be sent, got code %d", got.Code)
+	}
+}
+
+func TestRun_ExpectedStatus(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer s.Close()
+
+	tests := []struct {
+		name           string
+		expectedStatus string
+		wantHealthy    bool
+	}{
+		{
+			name:        "default rejects 500",
+			wantHealthy: false,
+		},
+		{
+			name:           "500 accepted",
+			expectedStatus: "200-299,500",
+			wantHealthy:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hc := &models.HealthCheck{
+				Endpoint:       s.URL,
+				ExpectedStatus: tt.expectedStatus,
+			}
+
+			got := Run(hc, 1*time.Second)
+			if got.Healthy != tt.wantHealthy {
+				t.Errorf("got healthy %v, expected %v", got.Healthy, tt.wantHealthy)
+			}
+			if !got.Healthy && got.Error == "" {
+				t.Error("expected unhealthy check to have an error")
+			}
+		})
+	}
+}
+
+func TestRun_Redirect(t *testing.T) {
+	var methods []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method+" "+r.URL.Path)
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		}
+	}))
+	defer s.Close()
+
+	hc := &models.HealthCheck{
+		Endpoint:       s.URL + "/old",
+		Method:         http.MethodPost,
+		Body:           `{"ping":true}`,
+		ExpectedStatus: "301",
+	}
+
+	got := Run(hc, 1*time.Second)
+	if got.Code != http.StatusMovedPermanently || !got.Healthy {
+		t.Errorf("expected the redirect to be checked as is, got code %d healthy %v err %s", got.Code, got.Healthy, got.Error)
+	}
+	if len(methods) != 1 || methods[0] != "POST /old" {
+		t.Errorf("expected a single POST /old, got %v", methods)
+	}
+}
+
+func TestRun_Assertions(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"degraded"}`))
+	}))
+	defer s.Close()
+
+	tests := []struct {
+		name         string
+		assertions   []models.Assertion
+		maxBodyBytes int64
+		wantHealthy  bool
+	}{
+		{
+			name:        "passes",
+			assertions:  []models.Assertion{{Type: AssertJSONPath, Path: "$.status", Value: "degraded"}},
+			wantHealthy: true,
+		},
+		{
+			name:        "fails",
+			assertions:  []models.Assertion{{Type: AssertJSONPath, Path: "$.status", Value: "ok"}},
+			wantHealthy: false,
+		},
+		{
+			name:         "body is capped",
+			assertions:   []models.Assertion{{Type: AssertContains, Value: "degraded"}},
+			maxBodyBytes: 5,
+			wantHealthy:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hc := &models.HealthCheck{
+				Endpoint:     s.URL,
+				Assertions:   tt.assertions,
+				MaxBodyBytes: tt.maxBodyBytes,
+			}
+
+			got := Run(hc, 1*time.Second)
+			if got.Healthy != tt.wantHealthy {
+				t.Errorf("got healthy %v, expected %v, err: %s", got.Healthy, tt.wantHealthy, got.Error)
+			}
+		})
+	}
+}
+
 func startServer(t *testing.T) *httptest.Server {
 	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -0,0 +1,208 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+// Window is a rolling window stats are computed over, from buckets of results Bucket long
+type Window struct {
+	Name   string
+	Length time.Duration
+	Bucket time.Duration
+}
+
+// Windows are the rolling windows returned for every healthcheck
+var Windows = []Window{
+	{Name: "1h", Length: time.Hour, Bucket: time.Minute},
+	{Name: "24h", Length: 24 * time.Hour, Bucket: time.Hour},
+	{Name: "7d", Length: 7 * 24 * time.Hour, Bucket: time.Hour},
+	{Name: "30d", Length: 30 * 24 * time.Hour, Bucket: time.Hour},
+}
+
+const (
+	// latencyGrowth is the ratio between the bounds of consecutive latency bins, percentiles are reported
+	// as the middle of their bin so they are within 2.5% of the exact value
+	latencyGrowth = 1.05
+	// minLatencyMs is the upper bound of the first latency bin
+	minLatencyMs = 0.01
+)
+
+// Aggregate counts the results of a healthcheck in per minute and per hour buckets, kept for the longest
+// window whatever the history depth and retention. Latencies of healthy results are counted in log scaled
+// bins so percentiles can be computed without keeping every result. It is not safe for concurrent use
+type Aggregate struct {
+	first int64
+	rings map[time.Duration]*ring
+}
+
+// NewAggregate returns an empty aggregate with enough buckets for every window
+func NewAggregate() *Aggregate {
+	a := &Aggregate{rings: make(map[time.Duration]*ring)}
+	for _, w := range Windows {
+		r, ok := a.rings[w.Bucket]
+		if !ok {
+			r = &ring{width: int64(w.Bucket / time.Second)}
+			a.rings[w.Bucket] = r
+		}
+		// one more bucket than the window holds as its oldest bucket is only partly in it
+		if n := int(w.Length/w.Bucket) + 1; n > len(r.buckets) {
+			r.buckets = make([]*bucket, n)
+		}
+	}
+	return a
+}
+
+// Add counts a result in the buckets it was checked in
+func (a *Aggregate) Add(r models.Result) {
+	if a.first == 0 || r.Checked < a.first {
+		a.first = r.Checked
+	}
+	for _, ring := range a.rings {
+		ring.add(r)
+	}
+}
+
+// Compute returns uptime and latency percentiles for each window ending at now. Uptime is the
+// percentage of healthy results, latency percentiles only include healthy results. Windows are
+// rounded out to whole buckets, Covered is shorter than the window when the first result is more recent
+func (a *Aggregate) Compute(now time.Time) []models.WindowStats {
+	stats := make([]models.WindowStats, 0, len(Windows))
+	for _, w := range Windows {
+		total := a.rings[w.Bucket].sum(now.Add(-w.Length).Unix(), now.Unix())
+
+		ws := models.WindowStats{Window: w.Name, Checks: total.checks}
+		if total.checks > 0 {
+			covered := w.Length
+			if since := time.Duration(now.Unix()-a.first) * time.Second; since < covered {
+				covered = since
+			}
+			ws.Covered = covered.String()
+			uptime := round(float64(total.healthy) / float64(total.checks) * 100)
+			ws.Uptime = &uptime
+		}
+		if total.healthy > 0 {
+			ws.P50 = total.percentile(50)
+			ws.P90 = total.percentile(90)
+			ws.P99 = total.percentile(99)
+		}
+
+		stats = append(stats, ws)
+	}
+	return stats
+}
+
+// ring is a circular buffer of buckets width seconds long, a bucket is reused once its slot comes round
+type ring struct {
+	width   int64
+	buckets []*bucket
+}
+
+func (r *ring) add(res models.Result) {
+	start := res.Checked - res.Checked%r.width
+	i := int(start / r.width % int64(len(r.buckets)))
+	b := r.buckets[i]
+	switch {
+	case b == nil || b.start < start:
+		b = &bucket{start: start}
+		r.buckets[i] = b
+	case b.start > start:
+		// older than the ring keeps
+		return
+	}
+	b.add(res)
+}
+
+// sum merges the buckets overlapping [from, to]
+func (r *ring) sum(from, to int64) *bucket {
+	total := &bucket{}
+	for _, b := range r.buckets {
+		if b != nil && b.start+r.width > from && b.start <= to {
+			total.merge(b)
+		}
+	}
+	return total
+}
+
+// bucket counts the results checked from start, latencies holds the count of healthy results per latency
+// bin sorted by bin
+type bucket struct {
+	start     int64
+	checks    int
+	healthy   int
+	latencies []binCount
+}
+
+type binCount struct {
+	bin   int
+	count int
+}
+
+func (b *bucket) add(r models.Result) {
+	b.checks++
+	if r.Healthy {
+		b.healthy++
+		b.count(latencyBin(r.DurationMs), 1)
+	}
+}
+
+func (b *bucket) merge(o *bucket) {
+	b.checks += o.checks
+	b.healthy += o.healthy
+	for _, l := range o.latencies {
+		b.count(l.bin, l.count)
+	}
+}
+
+// count adds n results to a latency bin
+func (b *bucket) count(bin, n int) {
+	i := sort.Search(len(b.latencies), func(i int) bool { return b.latencies[i].bin >= bin })
+	if i < len(b.latencies) && b.latencies[i].bin == bin {
+		b.latencies[i].count += n
+		return
+	}
+	b.latencies = append(b.latencies, binCount{})
+	copy(b.latencies[i+1:], b.latencies[i:])
+	b.latencies[i] = binCount{bin: bin, count: n}
+}
+
+// percentile returns the nearest-rank percentile of the healthy latencies
+func (b *bucket) percentile(p float64) *float64 {
+	rank := int(math.Ceil(p / 100 * float64(b.healthy)))
+	if rank < 1 {
+		rank = 1
+	}
+	seen := 0
+	for _, l := range b.latencies {
+		seen += l.count
+		if seen >= rank {
+			v := round(latencyValue(l.bin))
+			return &v
+		}
+	}
+	return nil
+}
+
+// latencyBin returns the bin of a latency, bin n holds latencies up to minLatencyMs * latencyGrowth^n
+func latencyBin(ms float64) int {
+	if ms <= minLatencyMs {
+		return 0
+	}
+	return int(math.Ceil(math.Log(ms/minLatencyMs) / math.Log(latencyGrowth)))
+}
+
+// latencyValue returns the geometric middle of a latency bin
+func latencyValue(bin int) float64 {
+	if bin == 0 {
+		return minLatencyMs
+	}
+	return minLatencyMs * math.Pow(latencyGrowth, float64(bin)-0.5)
+}
+
+// round rounds to 3 decimal places
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,129 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+func TestAggregate_Compute(t *testing.T) {
+	now := time.Now()
+	result := func(ago time.Duration, healthy bool, ms float64) models.Result {
+		return models.Result{Checked: now.Add(-ago).Unix(), Healthy: healthy, DurationMs: ms}
+	}
+
+	a := NewAggregate()
+	// 100 healthy results in the last hour with latencies 1..100ms
+	for i := 1; i <= 100; i++ {
+		a.Add(result(time.Duration(i)*time.Second, true, float64(i)))
+	}
+	// failures outside the hour but within the day
+	a.Add(result(2*time.Hour, false, 1000))
+	a.Add(result(3*time.Hour, false, 1000))
+	// healthy result 10 days ago
+	a.Add(result(10*24*time.Hour, true, 500))
+	// older than every window
+	a.Add(result(40*24*time.Hour, false, 1000))
+
+	tests := []struct {
+		window   string
+		covered  string
+		checks   int
+		uptime   float64
+		p50, p99 float64
+	}{
+		{window: "1h", covered: "1h0m0s", checks: 100, uptime: 100, p50: 50, p99: 99},
+		{window: "24h", covered: "24h0m0s", checks: 102, uptime: 98.039, p50: 50, p99: 99},
+		{window: "7d", covered: "168h0m0s", checks: 102, uptime: 98.039, p50: 50, p99: 99},
+		{window: "30d", covered: "720h0m0s", checks: 103, uptime: 98.058, p50: 51, p99: 100},
+	}
+
+	got := a.Compute(now)
+	if len(got) != len(tests) {
+		t.Fatalf("expected %d windows, got %+v", len(tests), got)
+	}
+	for i, tt := range tests {
+		ws := got[i]
+		if ws.Window != tt.window || ws.Covered != tt.covered || ws.Checks != tt.checks {
+			t.Errorf("got window %s covering %s with %d checks, expected %s covering %s with %d", ws.Window, ws.Covered, ws.Checks, tt.window, tt.covered, tt.checks)
+			continue
+		}
+		if ws.Uptime == nil || *ws.Uptime != tt.uptime {
+			t.Errorf("%s: got uptime %v, expected %v", tt.window, ws.Uptime, tt.uptime)
+		}
+		if !near(ws.P50, tt.p50) || !near(ws.P99, tt.p99) {
+			t.Errorf("%s: got p50 %v p99 %v, expected about %v %v", tt.window, ws.P50, ws.P99, tt.p50, tt.p99)
+		}
+	}
+}
+
+func TestAggregate_Covered(t *testing.T) {
+	now := time.Now()
+	a := NewAggregate()
+	a.Add(models.Result{Checked: now.Add(-100 * time.Second).Unix(), Healthy: true, DurationMs: 10})
+
+	for _, ws := range a.Compute(now) {
+		if ws.Covered != "1m40s" || ws.Checks != 1 {
+			t.Errorf("%s: expected 1 check covering 1m40s, got %d covering %s", ws.Window, ws.Checks, ws.Covered)
+		}
+	}
+}
+
+func TestAggregate_NoResults(t *testing.T) {
+	got := NewAggregate().Compute(time.Now())
+	if len(got) != len(Windows) {
+		t.Fatalf("expected every window, got %+v", got)
+	}
+	for i, ws := range got {
+		if ws.Window != Windows[i].Name || ws.Checks != 0 || ws.Covered != "" || ws.Uptime != nil || ws.P50 != nil {
+			t.Errorf("expected empty stats for %s, got %+v", Windows[i].Name, ws)
+		}
+	}
+}
+
+func TestAggregate_Ring(t *testing.T) {
+	// 61 minutes apart so both results land in the same slot of the minute ring
+	now := time.Unix(1600000000, 0)
+	recent := models.Result{Checked: now.Unix(), Healthy: true, DurationMs: 10}
+	old := models.Result{Checked: now.Add(-61 * time.Minute).Unix(), Healthy: false}
+
+	tests := []struct {
+		name    string
+		results []models.Result
+	}{
+		{name: "recent replaces old", results: []models.Result{old, recent}},
+		{name: "old is dropped", results: []models.Result{recent, old}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAggregate()
+			for _, r := range tt.results {
+				a.Add(r)
+			}
+			ws := a.Compute(now)
+			if ws[0].Checks != 1 || *ws[0].Uptime != 100 {
+				t.Errorf("expected only the recent result in 1h, got %+v", ws[0])
+			}
+			if ws[1].Checks != 2 {
+				t.Errorf("expected both results in 24h, got %+v", ws[1])
+			}
+		})
+	}
+}
+
+func TestLatencyBin(t *testing.T) {
+	for _, ms := range []float64{0, 0.01, 0.5, 1, 42, 999.9, 30000} {
+		got := latencyValue(latencyBin(ms))
+		if math.Abs(got-ms) > ms*0.025+minLatencyMs {
+			t.Errorf("latency %v reported as %v", ms, got)
+		}
+	}
+}
+
+// near reports whether a percentile is within the error of its latency bin
+func near(got *float64, want float64) bool {
+	return got != nil && math.Abs(*got-want) <= want*0.025
+}
@@ -7,9 +7,13 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/dnguy078/healthcheck/pkg/models"
+	"github.com/dnguy078/healthcheck/pkg/stats"
 )
 
 // Collection holds a map of healthchecks
@@ -18,13 +22,29 @@ type Collection struct {
 	sync.RWMutex
 	data           map[string]*models.HealthCheck
 	registeredURLs map[string]bool
+	history        map[string]*history
+	aggregates     map[string]*stats.Aggregate
+	maintenance    map[string]*models.MaintenanceWindow
+
+	historyDepth     int
+	historyRetention time.Duration
 }
 
+var (
+	defaultHistoryDepth     = 1000
+	defaultHistoryRetention = 30 * 24 * time.Hour
+)
+
 // NewCollection returns a new Collection
 func NewCollection(filePath string) *Collection {
 	c := &Collection{
-		data:           map[string]*models.HealthCheck{},
-		registeredURLs: make(map[string]bool),
+		data:             map[string]*models.HealthCheck{},
+		registeredURLs:   make(map[string]bool),
+		history:          make(map[string]*history),
+		aggregates:       make(map[string]*stats.Aggregate),
+		maintenance:      make(map[string]*models.MaintenanceWindow),
+		historyDepth:     defaultHistoryDepth,
+		historyRetention: defaultHistoryRetention,
 	}
 	if err := c.Load(filePath); err != nil {
 		log.Printf("unable to load any existing healthchecks from disk, err: %s", err)
@@ -33,19 +53,38 @@ func NewCollection(filePath string) *Collection {
 	return c
 }
 
-// List returns a list of healthchecks
+// SetHistoryLimits sets how many results are kept per healthcheck and for how long, a depth of 0 disables
+// history and a retention of 0 keeps results until they are overwritten. Negative limits are rejected.
+// Existing history is cleared
+func (c *Collection) SetHistoryLimits(depth int, retention time.Duration) error {
+	if depth < 0 {
+		return fmt.Errorf("history depth %d cannot be negative", depth)
+	}
+	if retention < 0 {
+		return fmt.Errorf("history retention %s cannot be negative", retention)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	c.historyDepth = depth
+	c.historyRetention = retention
+	c.history = make(map[string]*history)
+	return nil
+}
+
+// List returns a copy of every healthcheck
 func (c *Collection) List() models.HealthChecks {
 	items := make([]*models.HealthCheck, 0)
 	c.RLock()
-	c.RUnlock()
-	for _, c := range c.data {
-		items = append(items, c)
+	defer c.RUnlock()
+	for _, hc := range c.data {
+		items = append(items, hc.Copy())
 	}
 
 	return items
 }
 
-// Get returns a specific heallthcheck, errors if healthcheck does not exist
+// Get returns a copy of a specific heallthcheck, errors if healthcheck does not exist
 func (c *Collection) Get(id string) (*models.HealthCheck, error) {
 	c.RLock()
 	defer c.RUnlock()
@@ -54,7 +93,7 @@ func (c *Collection) Get(id string) (*models.HealthCheck, error) {
 		return nil, fmt.Errorf("healthcheck %s not found", id)
 	}
 
-	return hc, nil
+	return hc.Copy(), nil
 }
 
 // Create adds a healthcheck to the collection
@@ -69,20 +108,243 @@ func (c *Collection) Create(input *models.HealthCheck) error {
 	return fmt.Errorf("endpoint %s already registered", input.Endpoint)
 }
 
-// Delete removes a healthcheck from the collection
-func (c *Collection) Delete(id string) {
+// CreateBatch adds healthchecks to the collection in a single step, other writes cannot interleave and
+// readers see all of the batch or none of it. Returns an error for each healthcheck whose endpoint is
+// already registered, or repeated earlier in the batch, the others are added. Nothing is added on a dry run
+func (c *Collection) CreateBatch(input []*models.HealthCheck, dryRun bool) []error {
+	c.Lock()
+	defer c.Unlock()
+	errs := make([]error, len(input))
+	seen := map[string]bool{}
+	for i, hc := range input {
+		if c.registeredURLs[hc.Endpoint] || seen[hc.Endpoint] {
+			errs[i] = fmt.Errorf("endpoint %s already registered", hc.Endpoint)
+			continue
+		}
+		seen[hc.Endpoint] = true
+	}
+	if dryRun {
+		return errs
+	}
+
+	for i, hc := range input {
+		if errs[i] == nil {
+			c.data[hc.ID] = hc
+			c.registeredURLs[hc.Endpoint] = true
+		}
+	}
+	return errs
+}
+
+// Update replaces a healthcheck's configuration, keeping its stored result, state and history. Errors if
+// the healthcheck does not exist or its new endpoint is registered by another healthcheck
+func (c *Collection) Update(input *models.HealthCheck) error {
 	c.Lock()
 	defer c.Unlock()
-	if hc, found := c.data[id]; found {
-		delete(c.registeredURLs, hc.Endpoint)
-		delete(c.data, id)
+	existing, ok := c.data[input.ID]
+	if !ok {
+		return fmt.Errorf("healthcheck %s not found", input.ID)
+	}
+
+	if input.Endpoint != existing.Endpoint {
+		if c.registeredURLs[input.Endpoint] {
+			return fmt.Errorf("endpoint %s already registered", input.Endpoint)
+		}
+		delete(c.registeredURLs, existing.Endpoint)
+		c.registeredURLs[input.Endpoint] = true
 	}
+
+	hc := input.Copy()
+	hc.Result = existing.Result
+	hc.Tracking = existing.Tracking
+	hc.Stats = nil
+	c.data[hc.ID] = hc
+	return nil
 }
 
-// Dump takes all existing healthchecks and writes them to disk in JSON format
+// Record stores the result and state of a healthcheck run, errors if the healthcheck was deleted while it
+// was running
+func (c *Collection) Record(result *models.HealthCheck) error {
+	c.Lock()
+	defer c.Unlock()
+	hc, ok := c.data[result.ID]
+	if !ok {
+		return fmt.Errorf("healthcheck %s not found", result.ID)
+	}
+
+	hc.Result = result.Result.Copy()
+	hc.Tracking = result.Tracking.Copy()
+
+	h, ok := c.history[hc.ID]
+	if !ok {
+		h = newHistory(c.historyDepth)
+		c.history[hc.ID] = h
+	}
+	var cutoff time.Time
+	if c.historyRetention > 0 {
+		cutoff = time.Now().Add(-c.historyRetention)
+	}
+	h.Add(result.Result.Copy(), cutoff)
+
+	a, ok := c.aggregates[hc.ID]
+	if !ok {
+		a = stats.NewAggregate()
+		c.aggregates[hc.ID] = a
+	}
+	a.Add(result.Result)
+	return nil
+}
+
+// History returns the results of a healthcheck checked within [from, to], newest first. A zero from or
+// to leaves that end unbounded
+func (c *Collection) History(id string, from, to time.Time) ([]models.Result, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if _, ok := c.data[id]; !ok {
+		return nil, fmt.Errorf("healthcheck %s not found", id)
+	}
+
+	h, ok := c.history[id]
+	if !ok {
+		return []models.Result{}, nil
+	}
+	return h.Between(from, to), nil
+}
+
+// Stats returns the stats of a healthcheck for each window ending at now. They are kept apart from the
+// history so they are unaffected by the history limits
+func (c *Collection) Stats(id string, now time.Time) ([]models.WindowStats, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if _, ok := c.data[id]; !ok {
+		return nil, fmt.Errorf("healthcheck %s not found", id)
+	}
+
+	a, ok := c.aggregates[id]
+	if !ok {
+		a = stats.NewAggregate()
+	}
+	return a.Compute(now), nil
+}
+
+// Delete removes a healthcheck from the collection, errors if it does not exist
+func (c *Collection) Delete(id string) error {
+	c.Lock()
+	defer c.Unlock()
+	hc, found := c.data[id]
+	if !found {
+		return fmt.Errorf("healthcheck %s not found", id)
+	}
+	delete(c.registeredURLs, hc.Endpoint)
+	delete(c.history, id)
+	delete(c.aggregates, id)
+	delete(c.data, id)
+	return nil
+}
+
+// DeleteBatch removes healthchecks from the collection in a single step. Returns an error for each id that
+// does not exist, the others are removed. Nothing is removed on a dry run
+func (c *Collection) DeleteBatch(ids []string, dryRun bool) []error {
+	c.Lock()
+	defer c.Unlock()
+	errs := make([]error, len(ids))
+	seen := map[string]bool{}
+	for i, id := range ids {
+		if _, found := c.data[id]; !found || seen[id] {
+			errs[i] = fmt.Errorf("healthcheck %s not found", id)
+			continue
+		}
+		seen[id] = true
+	}
+	if dryRun {
+		return errs
+	}
+
+	for i, id := range ids {
+		if errs[i] == nil {
+			delete(c.registeredURLs, c.data[id].Endpoint)
+			delete(c.history, id)
+			delete(c.aggregates, id)
+			delete(c.data, id)
+		}
+	}
+	return errs
+}
+
+// ListMaintenance returns a copy of every maintenance window sorted by id
+func (c *Collection) ListMaintenance() []*models.MaintenanceWindow {
+	c.RLock()
+	defer c.RUnlock()
+	windows := make([]*models.MaintenanceWindow, 0, len(c.maintenance))
+	for _, mw := range c.maintenance {
+		windows = append(windows, mw.Copy())
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i].ID < windows[j].ID })
+	return windows
+}
+
+// GetMaintenance returns a copy of a maintenance window, errors if it does not exist
+func (c *Collection) GetMaintenance(id string) (*models.MaintenanceWindow, error) {
+	c.RLock()
+	defer c.RUnlock()
+	mw, ok := c.maintenance[id]
+	if !ok {
+		return nil, fmt.Errorf("maintenance window %s not found", id)
+	}
+	return mw.Copy(), nil
+}
+
+// CreateMaintenance adds a maintenance window, errors if its id is taken
+func (c *Collection) CreateMaintenance(mw *models.MaintenanceWindow) error {
+	c.Lock()
+	defer c.Unlock()
+	if _, ok := c.maintenance[mw.ID]; ok {
+		return fmt.Errorf("maintenance window %s already exists", mw.ID)
+	}
+	c.maintenance[mw.ID] = mw.Copy()
+	return nil
+}
+
+// UpdateMaintenance replaces a maintenance window, errors if it does not exist
+func (c *Collection) UpdateMaintenance(mw *models.MaintenanceWindow) error {
+	c.Lock()
+	defer c.Unlock()
+	if _, ok := c.maintenance[mw.ID]; !ok {
+		return fmt.Errorf("maintenance window %s not found", mw.ID)
+	}
+	c.maintenance[mw.ID] = mw.Copy()
+	return nil
+}
+
+// DeleteMaintenance removes a maintenance window, errors if it does not exist
+func (c *Collection) DeleteMaintenance(id string) error {
+	c.Lock()
+	defer c.Unlock()
+	if _, ok := c.maintenance[id]; !ok {
+		return fmt.Errorf("maintenance window %s not found", id)
+	}
+	delete(c.maintenance, id)
+	return nil
+}
+
+// maintenanceFile returns where maintenance windows are kept alongside the healthchecks file, ie)
+// data.json -> data.maintenance.json
+func maintenanceFile(filePath string) string {
+	ext := filepath.Ext(filePath)
+	return strings.TrimSuffix(filePath, ext) + ".maintenance" + ext
+}
+
+// Dump takes all existing healthchecks and writes them to disk in JSON format, maintenance windows are
+// written to a file alongside them
 func (c *Collection) Dump(fileName string) error {
-	list := c.List()
-	b, err := json.Marshal(list)
+	if err := writeJSON(fileName, c.List()); err != nil {
+		return err
+	}
+	return writeJSON(maintenanceFile(fileName), c.ListMaintenance())
+}
+
+func writeJSON(fileName string, v interface{}) error {
+	b, err := json.Marshal(v)
 	if err != nil {
 		return err
 	}
@@ -103,6 +365,7 @@ func (c *Collection) Dump(fileName string) error {
 }
 
 // Load reads a file container healthchecks written as JSON and populates the collection with existing healthchecks
+// and maintenance windows
 func (c *Collection) Load(filePath string) error {
 	absPath, err := filepath.Abs(filePath)
 	if err != nil {
@@ -121,8 +384,37 @@ func (c *Collection) Load(filePath string) error {
 	}
 
 	for _, h := range list {
+		if h.State == "" {
+			h.State = models.StateUnknown
+		}
 		c.data[h.ID] = h
+		c.registeredURLs[h.Endpoint] = true
 	}
 
+	return c.loadMaintenance(maintenanceFile(filePath))
+}
+
+// loadMaintenance reads maintenance windows written by Dump, a missing file is not an error
+func (c *Collection) loadMaintenance(filePath string) error {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return err
+	}
+
+	b, err := ioutil.ReadFile(absPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	windows := make([]*models.MaintenanceWindow, 0)
+	if err := json.Unmarshal(b, &windows); err != nil {
+		return err
+	}
+	for _, mw := range windows {
+		c.maintenance[mw.ID] = mw
+	}
 	return nil
 }
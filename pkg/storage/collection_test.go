@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/dnguy078/healthcheck/pkg/models"
 )
@@ -44,6 +45,7 @@ func TestCollection_Dump(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			defer os.Remove(testDumpFilePath)
+			defer os.Remove(maintenanceFile(testDumpFilePath))
 
 			c := NewCollection(testDumpFilePath)
 			c.Create(&models.HealthCheck{ID: "testID"})
@@ -59,3 +61,263 @@ func TestCollection_Dump(t *testing.T) {
 		})
 	}
 }
+
+func TestCollection_Maintenance(t *testing.T) {
+	defer os.Remove(testDumpFilePath)
+	defer os.Remove(maintenanceFile(testDumpFilePath))
+
+	c := NewCollection(testDumpFilePath)
+	mw := &models.MaintenanceWindow{ID: "window", CheckIDs: []string{"testID"}, Mode: models.MaintenanceSkip, Start: 100, End: 200}
+	if err := c.CreateMaintenance(mw); err != nil {
+		t.Fatalf("CreateMaintenance() error = %v", err)
+	}
+	if err := c.CreateMaintenance(mw); err == nil {
+		t.Error("expected an error creating a duplicate window")
+	}
+
+	// stored windows are copies
+	mw.CheckIDs[0] = "changed"
+	got, err := c.GetMaintenance("window")
+	if err != nil || got.CheckIDs[0] != "testID" {
+		t.Fatalf("GetMaintenance() = %+v, %v", got, err)
+	}
+
+	got.Mode = models.MaintenanceSuppress
+	if err := c.UpdateMaintenance(got); err != nil {
+		t.Fatalf("UpdateMaintenance() error = %v", err)
+	}
+	if err := c.UpdateMaintenance(&models.MaintenanceWindow{ID: "missing"}); err == nil {
+		t.Error("expected an error updating a missing window")
+	}
+
+	if err := c.Dump(testDumpFilePath); err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+	loaded := NewCollection(testDumpFilePath)
+	windows := loaded.ListMaintenance()
+	if len(windows) != 1 || windows[0].Mode != models.MaintenanceSuppress {
+		t.Errorf("expected the window to be loaded, got %+v", windows)
+	}
+
+	if err := c.DeleteMaintenance("window"); err != nil {
+		t.Fatalf("DeleteMaintenance() error = %v", err)
+	}
+	if err := c.DeleteMaintenance("window"); err == nil {
+		t.Error("expected an error deleting a missing window")
+	}
+	if len(c.ListMaintenance()) != 0 {
+		t.Error("expected no windows after delete")
+	}
+}
+
+func TestCollection_Record(t *testing.T) {
+	c := NewCollection("")
+	c.Create(&models.HealthCheck{ID: "testID", Endpoint: "https://www.blizzard.com"})
+
+	err := c.Record(&models.HealthCheck{ID: "testID", Endpoint: "ignored", Result: models.Result{Code: 200, Healthy: true, Checked: time.Now().Unix()}})
+	if err != nil {
+		t.Fatalf("expected to record result, err: %s", err)
+	}
+
+	hc, _ := c.Get("testID")
+	if hc.Code != 200 || !hc.Healthy || hc.Endpoint != "https://www.blizzard.com" {
+		t.Errorf("expected only the result to be stored, got %+v", hc)
+	}
+
+	if err := c.Record(&models.HealthCheck{ID: "deleted"}); err == nil {
+		t.Error("expected results of missing healthchecks to be dropped")
+	}
+
+	history, err := c.History("testID", time.Time{}, time.Time{})
+	if err != nil || len(history) != 1 {
+		t.Errorf("expected result to be added to history, got %v, err: %v", history, err)
+	}
+
+	if err := c.Delete("testID"); err != nil {
+		t.Errorf("Delete() error = %v", err)
+	}
+	if err := c.Delete("testID"); err == nil {
+		t.Error("expected an error deleting a missing healthcheck")
+	}
+	if _, err := c.History("testID", time.Time{}, time.Time{}); err == nil {
+		t.Error("expected history to be removed with the healthcheck")
+	}
+	if _, err := c.Stats("testID", time.Now()); err == nil {
+		t.Error("expected stats to be removed with the healthcheck")
+	}
+}
+
+func TestCollection_Stats(t *testing.T) {
+	c := NewCollection("")
+	if err := c.SetHistoryLimits(0, 0); err != nil {
+		t.Fatalf("SetHistoryLimits() error = %v", err)
+	}
+	c.Create(&models.HealthCheck{ID: "testID", Endpoint: "https://www.blizzard.com"})
+
+	now := time.Now()
+	for _, ago := range []time.Duration{time.Minute, 2 * 24 * time.Hour, 20 * 24 * time.Hour} {
+		if err := c.Record(&models.HealthCheck{ID: "testID", Result: models.Result{Healthy: true, Checked: now.Add(-ago).Unix()}}); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	windows, err := c.Stats("testID", now)
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+	// history is disabled yet every window counts the results within it
+	wantChecks := []int{1, 1, 2, 3}
+	for i, ws := range windows {
+		if ws.Checks != wantChecks[i] {
+			t.Errorf("%s: expected %d checks, got %d", ws.Window, wantChecks[i], ws.Checks)
+		}
+	}
+
+	if _, err := c.Stats("missing", now); err == nil {
+		t.Error("expected an error for a missing healthcheck")
+	}
+}
+
+func TestCollection_SetHistoryLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		depth     int
+		retention time.Duration
+		wantErr   bool
+	}{
+		{name: "limits", depth: 10, retention: time.Hour},
+		{name: "disabled", depth: 0, retention: 0},
+		{name: "negative depth", depth: -1, retention: time.Hour, wantErr: true},
+		{name: "negative retention", depth: 10, retention: -time.Hour, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCollection("")
+			if err := c.SetHistoryLimits(tt.depth, tt.retention); (err != nil) != tt.wantErr {
+				t.Fatalf("SetHistoryLimits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			c.Create(&models.HealthCheck{ID: "testID", Endpoint: "https://www.blizzard.com"})
+			if err := c.Record(&models.HealthCheck{ID: "testID", Result: models.Result{Healthy: true, Checked: time.Now().Unix()}}); err != nil {
+				t.Fatalf("Record() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestCollection_Update(t *testing.T) {
+	c := NewCollection(testDumpFilePath)
+	c.Create(&models.HealthCheck{ID: "a", Endpoint: "https://a.com"})
+	c.Create(&models.HealthCheck{ID: "b", Endpoint: "https://b.com"})
+	checked := time.Now().Unix()
+	c.Record(&models.HealthCheck{ID: "a", Endpoint: "https://a.com", Result: models.Result{Checked: checked, Healthy: true}})
+
+	tests := []struct {
+		name    string
+		input   *models.HealthCheck
+		wantErr bool
+	}{
+		{
+			name:    "missing",
+			input:   &models.HealthCheck{ID: "missing", Endpoint: "https://missing.com"},
+			wantErr: true,
+		},
+		{
+			name:    "endpoint registered by another check",
+			input:   &models.HealthCheck{ID: "a", Endpoint: "https://b.com"},
+			wantErr: true,
+		},
+		{
+			name:  "same endpoint",
+			input: &models.HealthCheck{ID: "a", Endpoint: "https://a.com", Interval: "10s"},
+		},
+		{
+			name:  "new endpoint",
+			input: &models.HealthCheck{ID: "a", Endpoint: "https://c.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := c.Update(tt.input); (err != nil) != tt.wantErr {
+				t.Errorf("Update() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	got, err := c.Get("a")
+	if err != nil || got.Endpoint != "https://c.com" || got.Checked != checked {
+		t.Errorf("expected the new endpoint with the previous result, got %+v, %v", got, err)
+	}
+	if history, _ := c.History("a", time.Time{}, time.Time{}); len(history) != 1 {
+		t.Errorf("expected the history to be kept, got %v", history)
+	}
+
+	// the old endpoint is free and the new one is taken
+	if err := c.Create(&models.HealthCheck{ID: "d", Endpoint: "https://a.com"}); err != nil {
+		t.Errorf("expected the previous endpoint to be released, got %v", err)
+	}
+	if err := c.Create(&models.HealthCheck{ID: "e", Endpoint: "https://c.com"}); err == nil {
+		t.Error("expected the new endpoint to be registered")
+	}
+}
+
+func TestCollection_LoadRegistersEndpoints(t *testing.T) {
+	c := NewCollection(testLoadFilePath)
+	list := c.List()
+	if len(list) != 1 {
+		t.Fatal("expected to load 1 healthcheck")
+	}
+	if err := c.Create(&models.HealthCheck{ID: "dup", Endpoint: list[0].Endpoint}); err == nil {
+		t.Error("expected loaded endpoints to be registered")
+	}
+}
+
+func TestCollection_CreateBatch(t *testing.T) {
+	c := NewCollection(testDumpFilePath)
+	c.Create(&models.HealthCheck{ID: "a", Endpoint: "https://a.com"})
+	batch := []*models.HealthCheck{
+		{ID: "b", Endpoint: "https://b.com"},
+		{ID: "dup", Endpoint: "https://a.com"},
+		{ID: "c", Endpoint: "https://c.com"},
+		{ID: "repeated", Endpoint: "https://c.com"},
+	}
+	wantErrs := []bool{false, true, false, true}
+
+	for _, dryRun := range []bool{true, false} {
+		errs := c.CreateBatch(batch, dryRun)
+		for i, err := range errs {
+			if (err != nil) != wantErrs[i] {
+				t.Errorf("dry run %v: item %d error = %v, wantErr %v", dryRun, i, err, wantErrs[i])
+			}
+		}
+		if want := map[bool]int{true: 1, false: 3}[dryRun]; len(c.List()) != want {
+			t.Errorf("dry run %v: expected %d healthchecks, got %d", dryRun, want, len(c.List()))
+		}
+	}
+	if err := c.Create(&models.HealthCheck{ID: "d", Endpoint: "https://b.com"}); err == nil {
+		t.Error("expected batch endpoints to be registered")
+	}
+}
+
+func TestCollection_DeleteBatch(t *testing.T) {
+	c := NewCollection(testDumpFilePath)
+	c.Create(&models.HealthCheck{ID: "a", Endpoint: "https://a.com"})
+	c.Create(&models.HealthCheck{ID: "b", Endpoint: "https://b.com"})
+	ids := []string{"a", "missing", "a"}
+	wantErrs := []bool{false, true, true}
+
+	for _, dryRun := range []bool{true, false} {
+		errs := c.DeleteBatch(ids, dryRun)
+		for i, err := range errs {
+			if (err != nil) != wantErrs[i] {
+				t.Errorf("dry run %v: item %d error = %v, wantErr %v", dryRun, i, err, wantErrs[i])
+			}
+		}
+		if want := map[bool]int{true: 2, false: 1}[dryRun]; len(c.List()) != want {
+			t.Errorf("dry run %v: expected %d healthchecks, got %d", dryRun, want, len(c.List()))
+		}
+	}
+	if err := c.Create(&models.HealthCheck{ID: "c", Endpoint: "https://a.com"}); err != nil {
+		t.Errorf("expected deleted endpoints to be released, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+// history is a ring buffer of up to depth healthcheck results, once full the oldest result is overwritten.
+// The buffer grows as results arrive so rarely run checks do not hold depth results
+type history struct {
+	results []models.Result
+	depth   int
+	start   int
+	size    int
+}
+
+func newHistory(depth int) *history {
+	return &history{depth: depth}
+}
+
+// Add appends a result and drops results checked before the cutoff
+func (h *history) Add(r models.Result, cutoff time.Time) {
+	if h.depth == 0 {
+		return
+	}
+
+	if h.size == len(h.results) {
+		if len(h.results) < h.depth {
+			h.grow()
+		} else {
+			h.start = (h.start + 1) % len(h.results)
+			h.size--
+		}
+	}
+	h.results[(h.start+h.size)%len(h.results)] = r
+	h.size++
+
+	for h.size > 0 && h.results[h.start].Checked < cutoff.Unix() {
+		h.results[h.start] = models.Result{}
+		h.start = (h.start + 1) % len(h.results)
+		h.size--
+	}
+}
+
+// grow doubles the buffer, up to depth, moving the oldest result to the front
+func (h *history) grow() {
+	n := 2 * len(h.results)
+	if n == 0 {
+		n = 8
+	}
+	if n > h.depth {
+		n = h.depth
+	}
+
+	results := make([]models.Result, n)
+	for i := 0; i < h.size; i++ {
+		results[i] = h.results[(h.start+i)%len(h.results)]
+	}
+	h.results = results
+	h.start = 0
+}
+
+// Between returns a copy of the results checked within [from, to], newest first. A zero from or to
+// leaves that end unbounded
+func (h *history) Between(from, to time.Time) []models.Result {
+	results := make([]models.Result, 0, h.size)
+	for i := h.size - 1; i >= 0; i-- {
+		r := h.results[(h.start+i)%len(h.results)]
+		if !from.IsZero() && r.Checked < from.Unix() {
+			continue
+		}
+		if !to.IsZero() && r.Checked > to.Unix() {
+			continue
+		}
+		results = append(results, r.Copy())
+	}
+	return results
+}
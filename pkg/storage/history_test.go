@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+)
+
+func TestHistory(t *testing.T) {
+	now := time.Now()
+	at := func(ago time.Duration) models.Result {
+		return models.Result{Checked: now.Add(-ago).Unix()}
+	}
+
+	tests := []struct {
+		name    string
+		depth   int
+		results []models.Result
+		cutoff  time.Time
+		from    time.Time
+		to      time.Time
+		want    []int64
+	}{
+		{
+			name:    "newest first",
+			depth:   5,
+			results: []models.Result{at(3 * time.Minute), at(2 * time.Minute), at(time.Minute)},
+			want:    []int64{now.Add(-time.Minute).Unix(), now.Add(-2 * time.Minute).Unix(), now.Add(-3 * time.Minute).Unix()},
+		},
+		{
+			name:    "oldest overwritten when full",
+			depth:   2,
+			results: []models.Result{at(3 * time.Minute), at(2 * time.Minute), at(time.Minute)},
+			want:    []int64{now.Add(-time.Minute).Unix(), now.Add(-2 * time.Minute).Unix()},
+		},
+		{
+			name:    "retention",
+			depth:   5,
+			results: []models.Result{at(3 * time.Hour), at(2 * time.Hour), at(time.Minute)},
+			cutoff:  now.Add(-90 * time.Minute),
+			want:    []int64{now.Add(-time.Minute).Unix()},
+		},
+		{
+			name:    "time range",
+			depth:   5,
+			results: []models.Result{at(3 * time.Minute), at(2 * time.Minute), at(time.Minute)},
+			from:    now.Add(-150 * time.Second),
+			to:      now.Add(-90 * time.Second),
+			want:    []int64{now.Add(-2 * time.Minute).Unix()},
+		},
+		{
+			name:    "disabled",
+			depth:   0,
+			results: []models.Result{at(time.Minute)},
+			want:    []int64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHistory(tt.depth)
+			for _, r := range tt.results {
+				h.Add(r, tt.cutoff)
+			}
+
+			got := h.Between(tt.from, tt.to)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d results, expected %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i].Checked != tt.want[i] {
+					t.Errorf("result %d checked at %d, expected %d", i, got[i].Checked, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHistory_Grows(t *testing.T) {
+	now := time.Now()
+	h := newHistory(20)
+	if len(h.results) != 0 {
+		t.Fatalf("expected nothing to be allocated up front, got %d", len(h.results))
+	}
+
+	// results older than the cutoff are pruned so the buffer wraps before it is full
+	for i := 0; i < 30; i++ {
+		h.Add(models.Result{Checked: now.Add(time.Duration(i) * time.Second).Unix()}, now.Add(time.Duration(i-5)*time.Second))
+	}
+	if len(h.results) != 8 {
+		t.Errorf("expected the buffer to grow only as needed, got %d", len(h.results))
+	}
+	for i := 30; i < 60; i++ {
+		h.Add(models.Result{Checked: now.Add(time.Duration(i) * time.Second).Unix()}, time.Time{})
+	}
+	if len(h.results) != 20 {
+		t.Errorf("expected the buffer to be capped at the depth, got %d", len(h.results))
+	}
+
+	got := h.Between(time.Time{}, time.Time{})
+	if len(got) != 20 {
+		t.Fatalf("got %d results, expected 20", len(got))
+	}
+	for i, r := range got {
+		if want := now.Add(time.Duration(59-i) * time.Second).Unix(); r.Checked != want {
+			t.Errorf("result %d checked at %d, expected %d", i, r.Checked, want)
+		}
+	}
+}
@@ -1,27 +1,235 @@
 package mocks
 
-import "github.com/dnguy078/healthcheck/pkg/models"
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dnguy078/healthcheck/pkg/models"
+	"github.com/dnguy078/healthcheck/pkg/stats"
+)
 
 type FakeCollection struct {
 	ListResp     models.HealthChecks
 	GetResp      *models.HealthCheck
 	GetErr       error
 	CreateErr    error
+	UpdateErr    error
 	CalledDelete bool
+	DeleteErr    error
+	RecordErr    error
+	HistoryResp  []models.Result
+	HistoryErr   error
+	// Maintenance holds the maintenance windows, keyed by id
+	Maintenance map[string]*models.MaintenanceWindow
+
+	mu       sync.Mutex
+	Recorded models.HealthChecks
+	// Created holds the healthchecks added by CreateBatch
+	Created models.HealthChecks
 }
 
 func (fc *FakeCollection) List() models.HealthChecks {
-	return fc.ListResp
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if fc.ListResp == nil {
+		return nil
+	}
+	list := make(models.HealthChecks, 0, len(fc.ListResp))
+	for _, hc := range fc.ListResp {
+		list = append(list, hc.Copy())
+	}
+	return list
 }
 
+// Get returns GetResp if set, otherwise the healthcheck with a matching id from ListResp
 func (fc *FakeCollection) Get(id string) (*models.HealthCheck, error) {
-	return fc.GetResp, fc.GetErr
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if fc.GetResp != nil || fc.GetErr != nil {
+		return fc.GetResp, fc.GetErr
+	}
+	for _, hc := range fc.ListResp {
+		if hc.ID == id {
+			return hc.Copy(), nil
+		}
+	}
+	return nil, fmt.Errorf("healthcheck %s not found", id)
 }
 
 func (fc *FakeCollection) Create(*models.HealthCheck) error {
 	return fc.CreateErr
 }
 
-func (fc *FakeCollection) Delete(id string) {
+// CreateBatch rejects endpoints in ListResp or repeated in the batch, keeping the others in Created
+func (fc *FakeCollection) CreateBatch(input []*models.HealthCheck, dryRun bool) []error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	registered := map[string]bool{}
+	for _, hc := range fc.ListResp {
+		registered[hc.Endpoint] = true
+	}
+	errs := make([]error, len(input))
+	for i, hc := range input {
+		if registered[hc.Endpoint] {
+			errs[i] = fmt.Errorf("endpoint %s already registered", hc.Endpoint)
+			continue
+		}
+		registered[hc.Endpoint] = true
+		if !dryRun {
+			fc.Created = append(fc.Created, hc)
+		}
+	}
+	return errs
+}
+
+// DeleteBatch removes the matching healthchecks from ListResp, erroring for ids it does not contain
+func (fc *FakeCollection) DeleteBatch(ids []string, dryRun bool) []error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	errs := make([]error, len(ids))
+	remove := map[string]bool{}
+	for i, id := range ids {
+		found := false
+		for _, hc := range fc.ListResp {
+			found = found || hc.ID == id
+		}
+		if !found || remove[id] {
+			errs[i] = fmt.Errorf("healthcheck %s not found", id)
+			continue
+		}
+		remove[id] = true
+	}
+	if dryRun {
+		return errs
+	}
+
+	kept := fc.ListResp[:0]
+	for _, hc := range fc.ListResp {
+		if !remove[hc.ID] {
+			kept = append(kept, hc)
+		}
+	}
+	fc.ListResp = kept
+	return errs
+}
+
+// Update replaces the configuration of the matching healthcheck in ListResp
+func (fc *FakeCollection) Update(input *models.HealthCheck) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if fc.UpdateErr != nil {
+		return fc.UpdateErr
+	}
+	for i, hc := range fc.ListResp {
+		if hc.ID == input.ID {
+			updated := input.Copy()
+			updated.Result = hc.Result
+			updated.Tracking = hc.Tracking
+			fc.ListResp[i] = updated
+			return nil
+		}
+	}
+	return fmt.Errorf("healthcheck %s not found", input.ID)
+}
+
+func (fc *FakeCollection) Delete(id string) error {
 	fc.CalledDelete = true
+	return fc.DeleteErr
+}
+
+// Record keeps every result and applies it to the matching healthcheck in ListResp
+func (fc *FakeCollection) Record(result *models.HealthCheck) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.Recorded = append(fc.Recorded, result)
+	for _, hc := range fc.ListResp {
+		if hc.ID == result.ID {
+			hc.Result = result.Result.Copy()
+			hc.Tracking = result.Tracking.Copy()
+		}
+	}
+	return fc.RecordErr
+}
+
+// RecordedCount returns how many results were recorded for a healthcheck
+func (fc *FakeCollection) RecordedCount(id string) int {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	count := 0
+	for _, hc := range fc.Recorded {
+		if hc.ID == id {
+			count++
+		}
+	}
+	return count
+}
+
+func (fc *FakeCollection) History(id string, from, to time.Time) ([]models.Result, error) {
+	return fc.HistoryResp, fc.HistoryErr
+}
+
+// Stats aggregates HistoryResp
+func (fc *FakeCollection) Stats(id string, now time.Time) ([]models.WindowStats, error) {
+	if fc.HistoryErr != nil {
+		return nil, fc.HistoryErr
+	}
+	a := stats.NewAggregate()
+	for _, r := range fc.HistoryResp {
+		a.Add(r)
+	}
+	return a.Compute(now), nil
+}
+
+// ListMaintenance returns copies of Maintenance sorted by id
+func (fc *FakeCollection) ListMaintenance() []*models.MaintenanceWindow {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	windows := make([]*models.MaintenanceWindow, 0, len(fc.Maintenance))
+	for _, mw := range fc.Maintenance {
+		windows = append(windows, mw.Copy())
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i].ID < windows[j].ID })
+	return windows
+}
+
+func (fc *FakeCollection) GetMaintenance(id string) (*models.MaintenanceWindow, error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	mw, ok := fc.Maintenance[id]
+	if !ok {
+		return nil, fmt.Errorf("maintenance window %s not found", id)
+	}
+	return mw.Copy(), nil
+}
+
+func (fc *FakeCollection) CreateMaintenance(mw *models.MaintenanceWindow) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if fc.Maintenance == nil {
+		fc.Maintenance = map[string]*models.MaintenanceWindow{}
+	}
+	fc.Maintenance[mw.ID] = mw.Copy()
+	return nil
+}
+
+func (fc *FakeCollection) UpdateMaintenance(mw *models.MaintenanceWindow) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if _, ok := fc.Maintenance[mw.ID]; !ok {
+		return fmt.Errorf("maintenance window %s not found", mw.ID)
+	}
+	fc.Maintenance[mw.ID] = mw.Copy()
+	return nil
+}
+
+func (fc *FakeCollection) DeleteMaintenance(id string) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if _, ok := fc.Maintenance[id]; !ok {
+		return fmt.Errorf("maintenance window %s not found", id)
+	}
+	delete(fc.Maintenance, id)
+	return nil
 }
@@ -7,7 +7,8 @@ import (
 )
 
 var (
-	uuidRegex = regexp.MustCompile(`[0-9a-fA-F]{8}\-[0-9a-fA-F]{4}\-[0-9a-fA-F]{4}\-[0-9a-fA-F]{4}\-[0-9a-fA-F]{12}`)
+	uuidRegex      = regexp.MustCompile(`[0-9a-fA-F]{8}\-[0-9a-fA-F]{4}\-[0-9a-fA-F]{4}\-[0-9a-fA-F]{4}\-[0-9a-fA-F]{12}`)
+	exactUUIDRegex = regexp.MustCompile(`^` + uuidRegex.String() + `$`)
 )
 
 func UUID() (string, error) {
@@ -24,6 +25,11 @@ func ContainsUUID(input string) bool {
 	return uuidRegex.MatchString(input)
 }
 
+// IsUUID returns whether the whole input is a uuid
+func IsUUID(input string) bool {
+	return exactUUIDRegex.MatchString(input)
+}
+
 func ExtractUUID(url string) string {
 	return uuidRegex.FindString(url)
 }
@@ -86,3 +86,23 @@ func TestExtractUUID(t *testing.T) {
 		})
 	}
 }
+
+func TestIsUUID(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "uuid", input: "11BB4040-7162-AC2D-8DD8-C98FFC7D871D", want: true},
+		{name: "lower case", input: "3b447fdf-d2e9-42bd-adcf-77d147b8b4dc", want: true},
+		{name: "contains uuid", input: "/api/health/checks/11BB4040-7162-AC2D-8DD8-C98FFC7D871D", want: false},
+		{name: "not uuid", input: "not a uid", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsUUID(tt.input); got != tt.want {
+				t.Errorf("IsUUID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}